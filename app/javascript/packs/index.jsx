@@ -14,7 +14,7 @@ export default class IndexCard extends React.Component {
     }
 
     getIndex() {
-        axios.get(`http://localhost:4000/`)
+        axios.get(`http://localhost:4000/?format=html`)
             .then(res => {
                 console.log(res.data.data)
                 this.setState({ posts: res.data.data });
@@ -28,7 +28,7 @@ export default class IndexCard extends React.Component {
                     <Card>
                         <CardTitle title={post.title} subtitle="Bin Joy" />
                         <CardText>
-                            {post.content}
+                            <div dangerouslySetInnerHTML={{ __html: post.content }} />
                         </CardText>
                         <CardActions>
                             <FlatButton label="Details" href={"http://localhost:3000/#/posts/" + post.id} />
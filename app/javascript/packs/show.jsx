@@ -13,7 +13,7 @@ export default class ShowCard extends React.Component {
     }
 
     getPost() {
-        axios.get(`http://localhost:4000/posts/${this.props.postId}`)
+        axios.get(`http://localhost:4000/posts/${this.props.postId}?format=html`)
             .then(res => {
                 console.log(res.data.data)
                 this.setState({ post: res.data.data });
@@ -29,8 +29,9 @@ export default class ShowCard extends React.Component {
                     avatar="https://avatars2.githubusercontent.com/u/1658618?v=4&s=460"
                 />
                 <CardTitle title={this.state.post.title} subtitle="2017/10/8" />
+                {/* the content is rendered from Markdown and sanitized by the API */}
                 <CardText>
-                    {this.state.post.content}
+                    <div dangerouslySetInnerHTML={{ __html: this.state.post.content }} />
                 </CardText>
             </Card>
         )
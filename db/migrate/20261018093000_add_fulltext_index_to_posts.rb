@@ -0,0 +1,8 @@
+class AddFulltextIndexToPosts < ActiveRecord::Migration[5.1]
+  def change
+    # the full-text search of the Go app, PostgreSQL has a tsvector index and SQLite an in-process one
+    if connection.adapter_name =~ /mysql/i
+      add_index :posts, [:title, :content], type: :fulltext
+    end
+  end
+end
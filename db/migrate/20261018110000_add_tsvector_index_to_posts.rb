@@ -0,0 +1,16 @@
+class AddTsvectorIndexToPosts < ActiveRecord::Migration[5.1]
+  # the document of the tsvector search of the Go app, it's the expression of its queries
+  DOCUMENT = "to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(content, ''))".freeze
+
+  def up
+    if connection.adapter_name =~ /postg/i
+      execute "CREATE INDEX index_posts_on_tsvector ON posts USING gin (#{DOCUMENT})"
+    end
+  end
+
+  def down
+    if connection.adapter_name =~ /postg/i
+      execute "DROP INDEX index_posts_on_tsvector"
+    end
+  end
+end
@@ -0,0 +1,356 @@
+// Command gorgen generates the models package from the Rails schema and models,
+// a file gor_<model>.go per ActiveRecord model with its CRUD, pagination and association functions,
+// and gor_schema.go with the SchemaVersion of the schema.
+//
+// The tables are read from db/schema.rb, or introspected from MySQL with -dsn, and the
+// belongs_to, has_many, validates and devise declarations from app/models/*.rb. Run it in go_app:
+//
+//	go run ./cmd/gorgen
+//	go run ./cmd/gorgen -dsn "root:@tcp(localhost:3306)/simple_example_with_admin_development"
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+//go:embed templates/model.go.tmpl
+var modelTemplate string
+
+//go:embed templates/schema.go.tmpl
+var schemaTemplate string
+
+// Model is the data of the template of a model file.
+type Model struct {
+	Source    string
+	Name      string
+	Table     string
+	Var       string
+	Plural    string
+	PluralVar string
+	Fields    []Field
+	BelongsTo []Assoc
+	HasMany   []Assoc
+	// RegisterValidator arguments after the model name
+	Validators []string
+	// the packages imported by the model file
+	Imports []string
+}
+
+// Field is a struct field mapped to a column.
+type Field struct {
+	Name   string
+	Column string
+	Type   string
+	JSON   string
+	Valid  string
+}
+
+// Assoc is an association of a model, for belongs_to the foreign key is on the model,
+// for has_many it's on the associated model.
+type Assoc struct {
+	Name            string
+	Assoc           string
+	Var             string
+	Model           string
+	ModelPlural     string
+	ForeignKey      string
+	ForeignKeyField string
+	ForeignKeyNull  bool
+}
+
+func main() {
+	schemaPath := flag.String("schema", "../db/schema.rb", "Rails schema file")
+	dsn := flag.String("dsn", "", "MySQL DSN to introspect instead of the schema file")
+	modelsDir := flag.String("models", "../app/models", "Rails models directory")
+	outDir := flag.String("out", "src/models", "Output directory of the models package")
+	flag.Parse()
+	log.SetFlags(0)
+
+	var schema *Schema
+	var err error
+	source := filepath.ToSlash(*schemaPath)
+	if *dsn != "" {
+		schema, err = introspectMySQL(*dsn)
+		source = "the MySQL schema"
+	} else {
+		schema, err = readSchema(*schemaPath)
+	}
+	if err != nil {
+		log.Fatalf("Read schema error: %v", err)
+	}
+	railsModels, err := readRailsModels(*modelsDir, func(format string, args ...interface{}) {
+		log.Printf("Warning: "+format, args...)
+	})
+	if err != nil {
+		log.Fatalf("Read models error: %v", err)
+	}
+	files, err := render(schema, railsModels, source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(*outDir, name)
+		if err := os.WriteFile(path, files[name], 0644); err != nil {
+			log.Fatalf("Write %s error: %v", path, err)
+		}
+		fmt.Fprintln(os.Stdout, "generated", path)
+	}
+}
+
+// render generates the files of the models package from the schema and the Rails models,
+// by their names: a gor_<model>.go per model and gor_schema.go.
+func render(schema *Schema, railsModels []RailsModel, source string) (map[string][]byte, error) {
+	models, err := buildModels(railsModels, schema.Tables, source)
+	if err != nil {
+		return nil, err
+	}
+	funcs := template.FuncMap{"quote": strconv.Quote}
+	modelTmpl := template.Must(template.New("model").Funcs(funcs).Parse(modelTemplate))
+	schemaTmpl := template.Must(template.New("schema").Funcs(funcs).Parse(schemaTemplate))
+	files := map[string][]byte{}
+	execute := func(tmpl *template.Template, data interface{}, name string) error {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("Render %s error: %w", name, err)
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("Format %s error: %w", name, err)
+		}
+		files[name] = src
+		return nil
+	}
+	for _, m := range models {
+		if err := execute(modelTmpl, m, "gor_"+underscore(m.Name)+".go"); err != nil {
+			return nil, err
+		}
+	}
+	if err := execute(schemaTmpl, map[string]string{"Source": source, "Version": schema.Version}, "gor_schema.go"); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// buildModels joins the Rails models to their tables, the models without a table are skipped.
+func buildModels(railsModels []RailsModel, tables []Table, source string) ([]*Model, error) {
+	byTable := map[string]Table{}
+	for _, t := range tables {
+		byTable[t.Name] = t
+	}
+	byName := map[string]*Model{}
+	models := []*Model{}
+	for _, rm := range railsModels {
+		t, ok := byTable[rm.Table]
+		if !ok {
+			log.Printf("Warning: no table %s of the model %s, skipped", rm.Table, rm.Name)
+			continue
+		}
+		m := newModel(rm, t, source)
+		byName[m.Name] = m
+		models = append(models, m)
+	}
+	// the associations need the models of both sides
+	for _, rm := range railsModels {
+		m := byName[rm.Name]
+		if m == nil {
+			continue
+		}
+		for _, a := range rm.BelongsTo {
+			target := byName[a.ClassName]
+			if target == nil {
+				return nil, fmt.Errorf("%s belongs_to %s: no model %s", rm.Name, a.Name, a.ClassName)
+			}
+			fk := m.field(a.ForeignKey)
+			if fk == nil {
+				return nil, fmt.Errorf("%s belongs_to %s: no column %s", rm.Name, a.Name, a.ForeignKey)
+			}
+			m.BelongsTo = append(m.BelongsTo, Assoc{Name: camelize(a.Name), Assoc: a.Name, Var: lowerCamel(a.Name),
+				Model: target.Name, ModelPlural: target.Plural, ForeignKey: a.ForeignKey,
+				ForeignKeyField: fk.Name, ForeignKeyNull: strings.HasPrefix(fk.Type, "Null[")})
+			if !a.Optional {
+				m.Validators = append(m.Validators, fmt.Sprintf("%q, BelongsTo(%q, %q)", a.Name, target.Table, a.ForeignKey))
+			}
+		}
+		for _, a := range rm.HasMany {
+			target := byName[a.ClassName]
+			if target == nil {
+				return nil, fmt.Errorf("%s has_many %s: no model %s", rm.Name, a.Name, a.ClassName)
+			}
+			fk := target.field(a.ForeignKey)
+			if fk == nil {
+				return nil, fmt.Errorf("%s has_many %s: no column %s on %s", rm.Name, a.Name, a.ForeignKey, target.Table)
+			}
+			m.HasMany = append(m.HasMany, Assoc{Name: camelize(a.Name), Assoc: a.Name, Var: lowerCamel(a.Name),
+				Model: target.Name, ModelPlural: target.Plural, ForeignKey: a.ForeignKey,
+				ForeignKeyField: fk.Name, ForeignKeyNull: strings.HasPrefix(fk.Type, "Null[")})
+		}
+	}
+	for _, m := range models {
+		m.Imports = m.imports()
+	}
+	return models, nil
+}
+
+// devise validatable validates the presence, the format and the uniqueness of the email.
+var deviseEmailValidation = []string{`presence: true, format: { with: /\A[^@\s]+@[^@\s]+\z/ }, uniqueness: { case_sensitive: false }`}
+
+func newModel(rm RailsModel, t Table, source string) *Model {
+	m := &Model{
+		Source:    source,
+		Name:      rm.Name,
+		Table:     t.Name,
+		Var:       lowerCamel(rm.Name),
+		Plural:    camelize(t.Name),
+		PluralVar: lowerCamel(t.Name),
+	}
+	validations := rm.Validations
+	for _, d := range rm.Devise {
+		if d == "validatable" {
+			validations["email"] = append(deviseEmailValidation, validations["email"]...)
+		}
+	}
+	for _, c := range t.Columns {
+		f := Field{Name: camelize(c.Name), Column: c.Name, Type: goType(c), JSON: c.Name, Valid: "-"}
+		switch {
+		case f.Type == "time.Time":
+			// omitempty doesn't leave out a zero struct
+			f.JSON += ",omitzero"
+		case !strings.HasPrefix(f.Type, "Null["):
+			f.JSON += ",omitempty"
+		}
+		if vs, ok := validations[c.Name]; ok {
+			var uniqueness string
+			f.Valid, uniqueness = validTag(vs, columnMaxLength(c))
+			if uniqueness != "" {
+				m.Validators = append(m.Validators, fmt.Sprintf("%q, %s", c.Name, uniqueness))
+			}
+		}
+		m.Fields = append(m.Fields, f)
+	}
+	return m
+}
+
+// imports lists the packages used by the model file, fmt and strings are for the has_many preloading.
+func (m *Model) imports() []string {
+	imports := []string{"context"}
+	if len(m.HasMany) > 0 {
+		imports = append(imports, "fmt", "strings")
+	}
+	for _, f := range m.Fields {
+		if strings.Contains(f.Type, "time.Time") {
+			return append(imports, "time")
+		}
+	}
+	return imports
+}
+
+func (m *Model) field(column string) *Field {
+	for i := range m.Fields {
+		if m.Fields[i].Column == column {
+			return &m.Fields[i]
+		}
+	}
+	return nil
+}
+
+// goType maps a Rails column type to the Go type of its field, a nullable column is a Null.
+func goType(c Column) string {
+	t := "string"
+	switch c.Type {
+	case "primary_key":
+		return "int64"
+	case "integer", "bigint":
+		t = "int64"
+	case "float", "decimal":
+		t = "float64"
+	case "boolean":
+		t = "bool"
+	case "datetime", "date", "time", "timestamp":
+		t = "time.Time"
+	case "binary":
+		t = "[]byte"
+	}
+	if c.Nullable {
+		return "Null[" + t + "]"
+	}
+	return t
+}
+
+// camelize converts a snake case name to camel case, e.g. user_id to UserId.
+func camelize(s string) string {
+	parts := strings.Split(s, "_")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func lowerCamel(s string) string {
+	s = camelize(s)
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// underscore converts a camel case name to snake case, e.g. BlogPost to blog_post.
+func underscore(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// tableize is the table name of a model class, e.g. BlogPost to blog_posts.
+func tableize(class string) string {
+	return pluralize(underscore(class))
+}
+
+func pluralize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && !strings.HasSuffix(s, "ay") && !strings.HasSuffix(s, "ey") && !strings.HasSuffix(s, "oy"):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	}
+	return s + "s"
+}
+
+func singularize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies"):
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "ses"), strings.HasSuffix(s, "xes"), strings.HasSuffix(s, "ches"), strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s"):
+		return s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGolden checks the committed models package is what gorgen generates from db/schema.rb and
+// app/models, run `go run ./cmd/gorgen` in go_app after a change of the schema, the models or the
+// templates.
+func TestGolden(t *testing.T) {
+	schema, err := readSchema("../../../db/schema.rb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	railsModels, err := readRailsModels("../../../app/models", t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the source named in the generated files is relative to go_app, where gorgen is run
+	files, err := render(schema, railsModels, "../db/schema.rb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := filepath.Glob("../../src/models/gor_*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(committed) != len(files) {
+		t.Errorf("%d gor_*.go files committed, %d generated", len(committed), len(files))
+	}
+	for name, src := range files {
+		want, err := os.ReadFile(filepath.Join("../../src/models", name))
+		if err != nil {
+			t.Errorf("generated %s isn't committed: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(src, want) {
+			t.Errorf("committed %s isn't the generated one, run go run ./cmd/gorgen", name)
+		}
+	}
+}
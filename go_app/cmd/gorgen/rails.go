@@ -0,0 +1,218 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RailsModel is the declarations of an ActiveRecord model read from app/models.
+type RailsModel struct {
+	Name        string
+	Table       string
+	BelongsTo   []RailsAssoc
+	HasMany     []RailsAssoc
+	Validations map[string][]string // the validates options by attribute, e.g. "presence: true"
+	Devise      []string            // the devise modules
+}
+
+// RailsAssoc is a belongs_to or has_many declaration.
+type RailsAssoc struct {
+	Name       string
+	ClassName  string
+	ForeignKey string
+	Optional   bool
+}
+
+var (
+	classRegexp      = regexp.MustCompile(`^\s*class\s+(\w+)\s*<\s*(ApplicationRecord|ActiveRecord::Base)\b`)
+	abstractRegexp   = regexp.MustCompile(`^\s*self\.abstract_class\s*=\s*true`)
+	tableNameRegexp  = regexp.MustCompile(`^\s*self\.table_name\s*=\s*["':](\w+)`)
+	assocRegexp      = regexp.MustCompile(`^\s*(belongs_to|has_many|has_one|has_and_belongs_to_many)\s+:(\w+)(.*)$`)
+	classNameRegexp  = regexp.MustCompile(`\bclass_name:\s*["'](\w+)["']`)
+	foreignKeyRegexp = regexp.MustCompile(`\bforeign_key:\s*["':](\w+)`)
+	optionalRegexp   = regexp.MustCompile(`\boptional:\s*true\b`)
+	throughRegexp    = regexp.MustCompile(`\bthrough:`)
+	validatesRegexp  = regexp.MustCompile(`^\s*validates\s+((?::\w+\s*,\s*)+)(.*)$`)
+	deviseRegexp     = regexp.MustCompile(`^\s*devise\s+(.*)$`)
+	symbolRegexp     = regexp.MustCompile(`:(\w+)`)
+)
+
+// readRailsModels parses the ActiveRecord models of the .rb files in dir,
+// the unsupported declarations are reported through warn.
+func readRailsModels(dir string, warn func(format string, args ...interface{})) ([]RailsModel, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.rb"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	models := []RailsModel{}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var model *RailsModel
+		abstract := false
+		lines := joinContinuations(strings.Split(string(b), "\n"))
+		for _, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), "#") {
+				continue
+			}
+			if m := classRegexp.FindStringSubmatch(line); m != nil {
+				model = &RailsModel{Name: m[1], Table: tableize(m[1]), Validations: map[string][]string{}}
+				continue
+			}
+			if model == nil {
+				continue
+			}
+			switch {
+			case abstractRegexp.MatchString(line):
+				abstract = true
+			case tableNameRegexp.MatchString(line):
+				model.Table = tableNameRegexp.FindStringSubmatch(line)[1]
+			case assocRegexp.MatchString(line):
+				m := assocRegexp.FindStringSubmatch(line)
+				a := RailsAssoc{Name: m[2], Optional: optionalRegexp.MatchString(m[3])}
+				if cm := classNameRegexp.FindStringSubmatch(m[3]); cm != nil {
+					a.ClassName = cm[1]
+				}
+				if fm := foreignKeyRegexp.FindStringSubmatch(m[3]); fm != nil {
+					a.ForeignKey = fm[1]
+				}
+				switch {
+				case m[1] == "belongs_to":
+					if a.ClassName == "" {
+						a.ClassName = camelize(a.Name)
+					}
+					if a.ForeignKey == "" {
+						a.ForeignKey = a.Name + "_id"
+					}
+					model.BelongsTo = append(model.BelongsTo, a)
+				case m[1] == "has_many" && !throughRegexp.MatchString(m[3]):
+					if a.ClassName == "" {
+						a.ClassName = camelize(singularize(a.Name))
+					}
+					if a.ForeignKey == "" {
+						a.ForeignKey = underscore(model.Name) + "_id"
+					}
+					model.HasMany = append(model.HasMany, a)
+				default:
+					warn("%s: %s %s isn't supported, skipped", file, m[1], a.Name)
+				}
+			case validatesRegexp.MatchString(line):
+				m := validatesRegexp.FindStringSubmatch(line)
+				for _, attr := range symbolRegexp.FindAllStringSubmatch(m[1], -1) {
+					model.Validations[attr[1]] = append(model.Validations[attr[1]], m[2])
+				}
+			case deviseRegexp.MatchString(line):
+				for _, mod := range symbolRegexp.FindAllStringSubmatch(deviseRegexp.FindStringSubmatch(line)[1], -1) {
+					model.Devise = append(model.Devise, mod[1])
+				}
+			}
+		}
+		if model != nil && !abstract {
+			models = append(models, *model)
+		}
+	}
+	return models, nil
+}
+
+// joinContinuations joins the lines ending with a comma to the next one,
+// e.g. a devise declaration over several lines.
+func joinContinuations(lines []string) []string {
+	joined := []string{}
+	cur := ""
+	for _, line := range lines {
+		cur += line
+		if strings.HasSuffix(strings.TrimSpace(line), ",") {
+			cur += " "
+			continue
+		}
+		joined = append(joined, cur)
+		cur = ""
+	}
+	if cur != "" {
+		joined = append(joined, cur)
+	}
+	return joined
+}
+
+var (
+	presenceRegexp      = regexp.MustCompile(`\bpresence:\s*true\b`)
+	lengthInRegexp      = regexp.MustCompile(`\blength:\s*\{[^}]*\b(?:in|within):\s*(\d+)\.\.(\d+)`)
+	lengthMinRegexp     = regexp.MustCompile(`\blength:\s*\{[^}]*\bminimum:\s*(\d+)`)
+	lengthMaxRegexp     = regexp.MustCompile(`\blength:\s*\{[^}]*\bmaximum:\s*(\d+)`)
+	formatRegexp        = regexp.MustCompile(`\bformat:\s*\{[^}]*\bwith:\s*/(.*?)/[a-z]*\s*[,}]`)
+	uniquenessRegexp    = regexp.MustCompile(`\buniqueness:\s*(true|\{[^}]*\})`)
+	caseInsensitiveExpr = regexp.MustCompile(`\bcase_sensitive:\s*false\b`)
+)
+
+// maxLength is the maximum of a length validation with a minimum only on a column that isn't
+// a string or a text, the size of a LONGTEXT.
+const maxLength = "4294967295"
+
+// columnMaxLength is the maximum of a length validation with a minimum only: the size of the
+// column as Rails creates it on MySQL, a VARCHAR(255) by default for a string and a TEXT for a text.
+func columnMaxLength(c Column) string {
+	switch c.Type {
+	case "string":
+		if c.Limit > 0 {
+			return strconv.Itoa(c.Limit)
+		}
+		return "255"
+	case "text":
+		if c.Limit == 0 {
+			return "65535"
+		}
+		// the TINYTEXT, TEXT, MEDIUMTEXT or LONGTEXT fitting the limit
+		for _, size := range []int{255, 65535, 16777215} {
+			if c.Limit <= size {
+				return strconv.Itoa(size)
+			}
+		}
+	}
+	return maxLength
+}
+
+// validTag converts the validates options of an attribute to a govalidator style valid tag,
+// the uniqueness is returned apart as it's checked by a DB-backed validator. columnMax is the
+// maximum of the length validations with a minimum only.
+func validTag(options []string, columnMax string) (tag string, uniqueness string) {
+	opts := []string{}
+	for _, o := range options {
+		if presenceRegexp.MatchString(o) {
+			opts = append(opts, "required")
+		}
+		switch {
+		case lengthInRegexp.MatchString(o):
+			m := lengthInRegexp.FindStringSubmatch(o)
+			opts = append(opts, "length("+m[1]+"|"+m[2]+")")
+		case lengthMinRegexp.MatchString(o) || lengthMaxRegexp.MatchString(o):
+			min, max := "0", columnMax
+			if m := lengthMinRegexp.FindStringSubmatch(o); m != nil {
+				min = m[1]
+			}
+			if m := lengthMaxRegexp.FindStringSubmatch(o); m != nil {
+				max = m[1]
+			}
+			opts = append(opts, "length("+min+"|"+max+")")
+		}
+		if m := formatRegexp.FindStringSubmatch(o); m != nil {
+			opts = append(opts, "matches("+m[1]+")")
+		}
+		if m := uniquenessRegexp.FindStringSubmatch(o); m != nil {
+			uniqueness = "Uniqueness(true)"
+			if caseInsensitiveExpr.MatchString(m[1]) {
+				uniqueness = "Uniqueness(false)"
+			}
+		}
+	}
+	if len(opts) == 0 {
+		return "-", uniqueness
+	}
+	return strings.Join(opts, ","), uniqueness
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Schema is the database schema, Version is the version of its latest migration.
+type Schema struct {
+	Version string
+	Tables  []Table
+}
+
+// Table is a table of the database schema.
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Column is a column of a table, Type is the Rails column type, e.g. string or datetime.
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Limit    int
+}
+
+var (
+	createTableRegexp = regexp.MustCompile(`^\s*create_table\s+"(\w+)"(.*)\bdo\s*\|\w+\|`)
+	columnRegexp      = regexp.MustCompile(`^\s*t\.(\w+)\s+"(\w+)"(.*)$`)
+	nullFalseRegexp   = regexp.MustCompile(`\bnull:\s*false\b`)
+	limitRegexp       = regexp.MustCompile(`\blimit:\s*(\d+)`)
+	idFalseRegexp     = regexp.MustCompile(`\bid:\s*false\b`)
+	versionRegexp     = regexp.MustCompile(`^\s*ActiveRecord::Schema(?:\[[\d.]+\])?\.define\(version:\s*([\d_]+)\)`)
+)
+
+// readSchema parses the version and the create_table blocks of a Rails db/schema.rb.
+func readSchema(path string) (*Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	schema := &Schema{}
+	tables := []Table{}
+	var table *Table
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := versionRegexp.FindStringSubmatch(line); m != nil {
+			schema.Version = strings.ReplaceAll(m[1], "_", "")
+			continue
+		}
+		if m := createTableRegexp.FindStringSubmatch(line); m != nil {
+			tables = append(tables, Table{Name: m[1]})
+			table = &tables[len(tables)-1]
+			if !idFalseRegexp.MatchString(m[2]) {
+				table.Columns = append(table.Columns, Column{Name: "id", Type: "primary_key"})
+			}
+			continue
+		}
+		if table == nil {
+			continue
+		}
+		if strings.TrimSpace(line) == "end" {
+			table = nil
+			continue
+		}
+		m := columnRegexp.FindStringSubmatch(line)
+		if m == nil || m[1] == "index" {
+			continue
+		}
+		c := Column{Name: m[2], Type: m[1], Nullable: !nullFalseRegexp.MatchString(m[3])}
+		switch c.Type {
+		case "references", "belongs_to":
+			c.Name, c.Type = c.Name+"_id", "integer"
+		case "timestamps":
+			table.Columns = append(table.Columns,
+				Column{Name: "created_at", Type: "datetime", Nullable: c.Nullable},
+				Column{Name: "updated_at", Type: "datetime", Nullable: c.Nullable})
+			continue
+		}
+		if lm := limitRegexp.FindStringSubmatch(m[3]); lm != nil {
+			fmt.Sscan(lm[1], &c.Limit)
+		}
+		table.Columns = append(table.Columns, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if schema.Version == "" {
+		return nil, fmt.Errorf("no ActiveRecord::Schema.define(version: ...) in %s", path)
+	}
+	schema.Tables = tables
+	return schema, nil
+}
+
+// introspectMySQL reads the tables of the current database of a MySQL DSN, and its version
+// from the schema_migrations table of the Rails app.
+func introspectMySQL(dsn string) (*Schema, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	schema := &Schema{}
+	if err := db.QueryRow("SELECT IFNULL(MAX(version), '') FROM schema_migrations").Scan(&schema.Version); err != nil {
+		return nil, fmt.Errorf("Read schema_migrations error: %w", err)
+	}
+	rows, err := db.Query(`SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, IS_NULLABLE = 'YES', COLUMN_KEY = 'PRI',
+		IFNULL(CHARACTER_MAXIMUM_LENGTH, 0) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, ORDINAL_POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tables := []Table{}
+	for rows.Next() {
+		var table, name, dataType, columnType string
+		var nullable, primary bool
+		var limit int
+		if err := rows.Scan(&table, &name, &dataType, &columnType, &nullable, &primary, &limit); err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 || tables[len(tables)-1].Name != table {
+			tables = append(tables, Table{Name: table})
+		}
+		c := Column{Name: name, Type: railsType(dataType, columnType), Nullable: nullable, Limit: limit}
+		if primary && name == "id" {
+			c.Type = "primary_key"
+		}
+		t := &tables[len(tables)-1]
+		t.Columns = append(t.Columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	schema.Tables = tables
+	return schema, nil
+}
+
+// railsType maps a MySQL data type to the Rails column type.
+func railsType(dataType, columnType string) string {
+	switch dataType {
+	case "varchar", "char", "enum", "set":
+		return "string"
+	case "tinytext", "text", "mediumtext", "longtext", "json":
+		return "text"
+	case "tinyint":
+		if columnType == "tinyint(1)" {
+			return "boolean"
+		}
+		return "integer"
+	case "smallint", "mediumint", "int":
+		return "integer"
+	case "bigint":
+		return "bigint"
+	case "float", "double", "decimal":
+		return "float"
+	case "date", "datetime", "timestamp", "time":
+		return "datetime"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return "binary"
+	}
+	return "string"
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// envPrefix prefixes the environment variables of the flags, e.g. GO_APP_PORT for -port.
+const envPrefix = "GO_APP_"
+
+// loadConfig parses the flags of fs from args, then fills the flags not given on the command line
+// from the environment variables and the YAML config file named by the flag -config, in that order
+// of precedence. The keys of the config file are the flag names, e.g.
+//
+//	port: 4000
+//	write-timeout: 30s
+func loadConfig(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	given := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = f.Value.String()
+	})
+
+	if f := fs.Lookup("config"); f != nil {
+		path := f.Value.String()
+		if _, ok := given["config"]; !ok {
+			if v, ok := os.LookupEnv(envPrefix + "CONFIG"); ok {
+				path = v
+			}
+		}
+		if path != "" {
+			if err := loadConfigFile(fs, path); err != nil {
+				return err
+			}
+		}
+	}
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		key := envPrefix + strings.ToUpper(strings.Replace(f.Name, "-", "_", -1))
+		if v, ok := os.LookupEnv(key); ok && err == nil {
+			if e := fs.Set(f.Name, v); e != nil {
+				err = fmt.Errorf("Invalid value of %s: %v", key, e)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for name, v := range given {
+		fs.Set(name, v)
+	}
+	return nil
+}
+
+func loadConfigFile(fs *flag.FlagSet, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Read config file error: %v", err)
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("Parse config file %s error: %v", path, err)
+	}
+	for name, v := range values {
+		if name == "config" || fs.Lookup(name) == nil {
+			return fmt.Errorf("Unknown key in config file %s: %s", path, name)
+		}
+		if err := fs.Set(name, fmt.Sprint(v)); err != nil {
+			return fmt.Errorf("Invalid value of %s in config file %s: %v", name, path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLoadConfigPrecedence checks a flag is taken from the command line, else the environment,
+// else the config file, else its default.
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	config := "port: 4001\ndb-driver: pgx\nslow-query: 1s\n"
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envPrefix+"CONFIG", path)
+	t.Setenv(envPrefix+"PORT", "4002")
+	t.Setenv(envPrefix+"DB_DRIVER", "sqlite3")
+
+	fs := flag.NewFlagSet("go_app", flag.ContinueOnError)
+	fs.String("config", "", "YAML config file")
+	port := fs.String("port", "4000", "Http Server Port")
+	dbDriver := fs.String("db-driver", "mysql", "Database driver")
+	slowQuery := fs.Duration("slow-query", 200*time.Millisecond, "Slow query threshold")
+	readyTimeout := fs.Duration("ready-timeout", 2*time.Second, "Readiness probe database timeout")
+	if err := loadConfig(fs, []string{"-port", "4003"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct{ name, got, want string }{
+		{"port of the command line", *port, "4003"},
+		{"db-driver of the environment", *dbDriver, "sqlite3"},
+		{"slow-query of the config file", slowQuery.String(), "1s"},
+		{"ready-timeout by default", readyTimeout.String(), "2s"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	for _, config := range []string{"unknown: 1\n", "config: other.yml\n", "slow-query: soon\n"} {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fs := flag.NewFlagSet("go_app", flag.ContinueOnError)
+		fs.String("config", "", "YAML config file")
+		fs.Duration("slow-query", 200*time.Millisecond, "Slow query threshold")
+		if err := loadConfig(fs, []string{"-config", path}); err == nil {
+			t.Errorf("loadConfig of the config file %q succeeded, want an error", config)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The Cache-Control of the assets: the fingerprinted ones never change so they're cached for a year,
+// the others, e.g. manifest.json or an asset requested by its logical name, are revalidated by their ETag.
+const (
+	fingerprintedCacheControl = "public, max-age=31536000, immutable"
+	revalidateCacheControl    = "public, no-cache"
+)
+
+// fingerprintRegexp matches the file names fingerprinted by webpack, e.g. application-4c4b9e5cd4a1c1c2e0f1.js.
+var fingerprintRegexp = regexp.MustCompile(`-[0-9a-f]{8,}(\.[\w-]+)+$`)
+
+// assetEncodings are the precompressed variants of an asset, by order of preference.
+var assetEncodings = []struct{ name, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// assetETag is the ETag of a file as of its modification time and size.
+type assetETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// Assets serves the webpacker output, the packs of fsys served on prefix. The logical names of
+// manifest.json, e.g. application.js, are resolved to their fingerprinted files.
+type Assets struct {
+	fsys   fs.FS
+	prefix string
+
+	mu           sync.Mutex
+	manifest     map[string]string
+	manifestTime time.Time
+	etags        map[string]assetETag
+}
+
+// NewAssets returns the Assets of fsys served on prefix, e.g. os.DirFS("../public/packs") on "/packs".
+func NewAssets(fsys fs.FS, prefix string) *Assets {
+	return &Assets{fsys: fsys, prefix: strings.TrimSuffix(prefix, "/"), etags: map[string]assetETag{}}
+}
+
+// LoadManifest reads manifest.json, again only if it was modified since it was read, so the packs
+// recompiled by the webpack dev server are picked up. The entries that aren't paths, e.g. the
+// entrypoints of webpacker 4, are left out.
+func (a *Assets) LoadManifest() error {
+	info, err := fs.Stat(a.fsys, "manifest.json")
+	if err != nil {
+		return fmt.Errorf("Read manifest.json error: %w", err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.manifest != nil && info.ModTime().Equal(a.manifestTime) {
+		return nil
+	}
+	data, err := fs.ReadFile(a.fsys, "manifest.json")
+	if err != nil {
+		return fmt.Errorf("Read manifest.json error: %w", err)
+	}
+	entries := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("Parse manifest.json error: %w", err)
+	}
+	manifest := map[string]string{}
+	for name, raw := range entries {
+		var p string
+		if json.Unmarshal(raw, &p) == nil {
+			manifest[name] = p
+		}
+	}
+	a.manifest, a.manifestTime = manifest, info.ModTime()
+	return nil
+}
+
+// Path resolves the logical name of an asset to its fingerprinted path, e.g. "application.js"
+// to "/packs/application-4c4b9e5cd4a1c1c2e0f1.js".
+func (a *Assets) Path(name string) (string, error) {
+	if err := a.LoadManifest(); err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p, ok := a.manifest[name]
+	if !ok {
+		return "", fmt.Errorf("no asset %q in manifest.json", name)
+	}
+	return p, nil
+}
+
+// etag is the strong ETag of a file, the SHA-256 of its content, computed again when it's modified.
+func (a *Assets) etag(name string, info fs.FileInfo) (string, error) {
+	a.mu.Lock()
+	cached, ok := a.etags[name]
+	a.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.etag, nil
+	}
+	data, err := fs.ReadFile(a.fsys, name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	a.mu.Lock()
+	a.etags[name] = assetETag{modTime: info.ModTime(), size: info.Size(), etag: etag}
+	a.mu.Unlock()
+	return etag, nil
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header accepts an encoding, i.e. lists it
+// or * without a q=0.
+func acceptsEncoding(header, encoding string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+		if name != encoding && name != "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		if name == encoding {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
+
+// AssetsHandler returns a handler serving the assets of a on its prefix, routed on prefix+"/*filepath".
+// The precompressed variant of an asset, e.g. application-4c4b9e5cd4a1c1c2e0f1.js.br, is served
+// if it exists and the client accepts its encoding. The conditional and range requests are
+// answered by http.ServeContent from the ETag.
+func AssetsHandler(a *Assets) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := strings.TrimPrefix(c.Param("filepath"), "/")
+		if !fs.ValidPath(name) || name == "." {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		fingerprinted := fingerprintRegexp.MatchString(path.Base(name))
+		if info, err := fs.Stat(a.fsys, name); err != nil || info.IsDir() {
+			// a logical name of the manifest
+			p, err := a.Path(name)
+			if err != nil || !strings.HasPrefix(p, a.prefix+"/") {
+				c.AbortWithStatus(http.StatusNotFound)
+				return
+			}
+			name, fingerprinted = strings.TrimPrefix(p, a.prefix+"/"), false
+		}
+
+		file, encoding := name, ""
+		c.Header("Vary", "Accept-Encoding")
+		for _, enc := range assetEncodings {
+			if !acceptsEncoding(c.GetHeader("Accept-Encoding"), enc.name) {
+				continue
+			}
+			if info, err := fs.Stat(a.fsys, name+enc.ext); err == nil && !info.IsDir() {
+				file, encoding = name+enc.ext, enc.name
+				break
+			}
+		}
+		f, err := a.fsys.Open(file)
+		if err != nil {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		etag, err := a.etag(file, info)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		content, ok := f.(io.ReadSeeker)
+		if !ok {
+			data, err := io.ReadAll(f)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			content = bytes.NewReader(data)
+		}
+
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			// don't let ServeContent sniff the compressed bytes
+			contentType = "application/octet-stream"
+		}
+		c.Header("Content-Type", contentType)
+		if encoding != "" {
+			c.Header("Content-Encoding", encoding)
+		}
+		c.Header("ETag", etag)
+		if fingerprinted {
+			c.Header("Cache-Control", fingerprintedCacheControl)
+		} else {
+			c.Header("Cache-Control", revalidateCacheControl)
+		}
+		http.ServeContent(c.Writer, c.Request, name, info.ModTime(), content)
+	}
+}
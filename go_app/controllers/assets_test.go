@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAssetsHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"manifest.json":                    {Data: []byte(`{"application.js": "/packs/application-4c4b9e5cd4a1.js", "entrypoints": {}}`)},
+		"application-4c4b9e5cd4a1.js":      {Data: []byte("console.log('plain')")},
+		"application-4c4b9e5cd4a1.js.br":   {Data: []byte("brotli")},
+		"application-4c4b9e5cd4a1.js.gz":   {Data: []byte("gzip")},
+		"application-4c4b9e5cd4a1.css":     {Data: []byte("body {}")},
+		"application-4c4b9e5cd4a1.css.gz":  {Data: []byte("gzip css")},
+		"media/images/logo.png":            {Data: []byte("png")},
+		"media/images/logo-0123456789.png": {Data: []byte("fingerprinted png")},
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/packs/*filepath", AssetsHandler(NewAssets(fsys, "/packs")))
+
+	get := func(target string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	// the ETag of a first request, for If-None-Match
+	etag := get("/packs/application-4c4b9e5cd4a1.js", nil).Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+
+	tests := []struct {
+		name         string
+		target       string
+		header       map[string]string
+		code         int
+		body         string
+		encoding     string
+		cacheControl string
+	}{
+		{"fingerprinted", "/packs/application-4c4b9e5cd4a1.js", nil, http.StatusOK, "console.log('plain')", "", fingerprintedCacheControl},
+		{"br preferred", "/packs/application-4c4b9e5cd4a1.js", map[string]string{"Accept-Encoding": "gzip, deflate, br"}, http.StatusOK, "brotli", "br", fingerprintedCacheControl},
+		{"gzip", "/packs/application-4c4b9e5cd4a1.js", map[string]string{"Accept-Encoding": "gzip"}, http.StatusOK, "gzip", "gzip", fingerprintedCacheControl},
+		{"br refused", "/packs/application-4c4b9e5cd4a1.js", map[string]string{"Accept-Encoding": "*, br;q=0"}, http.StatusOK, "gzip", "gzip", fingerprintedCacheControl},
+		{"no br variant", "/packs/application-4c4b9e5cd4a1.css", map[string]string{"Accept-Encoding": "br, gzip"}, http.StatusOK, "gzip css", "gzip", fingerprintedCacheControl},
+		{"logical name", "/packs/application.js", nil, http.StatusOK, "console.log('plain')", "", revalidateCacheControl},
+		{"logical name br", "/packs/application.js", map[string]string{"Accept-Encoding": "br"}, http.StatusOK, "brotli", "br", revalidateCacheControl},
+		{"not fingerprinted", "/packs/media/images/logo.png", nil, http.StatusOK, "png", "", revalidateCacheControl},
+		{"fingerprinted image", "/packs/media/images/logo-0123456789.png", nil, http.StatusOK, "fingerprinted png", "", fingerprintedCacheControl},
+		{"manifest", "/packs/manifest.json", nil, http.StatusOK, "", "", revalidateCacheControl},
+		{"if-none-match", "/packs/application-4c4b9e5cd4a1.js", map[string]string{"If-None-Match": etag}, http.StatusNotModified, "", "", fingerprintedCacheControl},
+		{"stale if-none-match", "/packs/application-4c4b9e5cd4a1.js", map[string]string{"If-None-Match": `"stale"`}, http.StatusOK, "console.log('plain')", "", fingerprintedCacheControl},
+		{"not a path entry", "/packs/entrypoints", nil, http.StatusNotFound, "", "", ""},
+		{"missing", "/packs/missing.js", nil, http.StatusNotFound, "", "", ""},
+		{"directory", "/packs/media/images", nil, http.StatusNotFound, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(tt.target, tt.header)
+			if w.Code != tt.code {
+				t.Fatalf("GET %s = %d, want %d", tt.target, w.Code, tt.code)
+			}
+			if tt.code == http.StatusNotFound {
+				return
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.cacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.cacheControl)
+			}
+			if w.Header().Get("ETag") == "" {
+				t.Error("no ETag")
+			}
+		})
+	}
+}
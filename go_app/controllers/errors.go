@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	m "go_app/src/models"
+)
+
+// RequestIDHeader is the header carrying the id of a request, it's taken from the
+// client if it's valid and echoed back in the response.
+const RequestIDHeader = "X-Request-Id"
+
+const requestIDKey = "request_id"
+
+// Problem is the RFC 7807 problem details body of an error response.
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	Errors    []m.FieldError `json:"errors,omitempty"`
+}
+
+// requestIDRegexp matches the request ids kept from the clients, at most 64 characters of the
+// UUID and base64 alphabets so they're safe in the headers, the logs and the pages.
+var requestIDRegexp = regexp.MustCompile(`^[A-Za-z0-9._:+/=-]{1,64}$`)
+
+// RequestID is a middleware to assign an id to every request, the one of its X-Request-Id
+// header if it's valid, else a new one.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDRegexp.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// ErrorHandler is a middleware to render the errors added by the handlers through c.Error
+// as problem details, or JSON:API errors if the client accepts them and an error page for
+// the browsers, the status code is chosen by the type of the last error.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		ginErr := c.Errors.Last()
+		if ginErr == nil || c.Writer.Written() {
+			return
+		}
+		status := errorStatus(ginErr)
+		p := Problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(status),
+			Status:    status,
+			Detail:    ginErr.Err.Error(),
+			Instance:  c.Request.URL.Path,
+			RequestID: c.GetString(requestIDKey),
+		}
+		var ve *m.ValidationError
+		if errors.As(ginErr.Err, &ve) {
+			p.Errors = ve.Errors
+		}
+		if status == http.StatusInternalServerError {
+			log.Printf("[%s] %v\n", p.RequestID, ginErr.Err)
+			p.Detail = ""
+		}
+		c.Header("Vary", "Accept")
+		if wantsJSONAPI(c) {
+			renderJSONAPIErrors(c, p)
+			return
+		}
+		if wantsHTMLPage(c) {
+			renderHTMLError(c, p)
+			return
+		}
+		c.Header("Content-Type", "application/problem+json")
+		c.JSON(status, p)
+	}
+}
+
+// errorStatus maps an error to the HTTP status code of the response.
+func errorStatus(ginErr *gin.Error) int {
+	switch {
+	case ginErr.IsType(gin.ErrorTypeBind):
+		return http.StatusBadRequest
+	case errors.Is(ginErr.Err, m.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(ginErr.Err, m.ErrDuplicate), errors.Is(ginErr.Err, m.ErrForeignKey):
+		return http.StatusConflict
+	case errors.Is(ginErr.Err, m.ErrValidation):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(requestIDKey)) })
+
+	for _, tt := range []struct {
+		header string
+		kept   bool
+	}{
+		{"", false},
+		{"3fa85f64-5717-4562-b3fc-2c963f66afa6", true},
+		{"req.42:abc+/=", true},
+		{strings.Repeat("a", 64), true},
+		{strings.Repeat("a", 65), false},
+		{"<script>alert(1)</script>", false},
+		{"id with spaces", false},
+		{"id\x00nul", false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header[RequestIDHeader] = []string{tt.header}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		id := w.Header().Get(RequestIDHeader)
+		if id != w.Body.String() {
+			t.Errorf("X-Request-Id %q: header %q, context %q, want the same", tt.header, id, w.Body.String())
+		}
+		if kept := id == tt.header; kept != tt.kept {
+			t.Errorf("X-Request-Id %q: id %q, kept = %v, want %v", tt.header, id, kept, tt.kept)
+		}
+		if !tt.kept && len(id) != 32 {
+			t.Errorf("X-Request-Id %q: new id %q, want 32 hex characters", tt.header, id)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// graphQLRequest is a GraphQL request, the JSON body of a POST or the params of a GET.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// defaultListSize is the size assumed for the complexity of a list field without a first argument.
+const defaultListSize = 10
+
+// GraphQLHandler serves the GraphQL schema of the models, the queries deeper than maxDepth
+// or more complex than maxComplexity are rejected before their execution. A field costs 1
+// and the cost of the fields of a list is multiplied by its first argument.
+// The mutations are only in the schema with a mutationToken, they must be authorized by it
+// as a bearer token, e.g. "Authorization: Bearer <mutationToken>".
+func GraphQLHandler(maxDepth, maxComplexity int, mutationToken string) gin.HandlerFunc {
+	schema, err := newGraphQLSchema(mutationToken != "")
+	if err != nil {
+		panic(fmt.Sprintf("GraphQL schema error: %v", err))
+	}
+	return func(c *gin.Context) {
+		req := graphQLRequest{}
+		if c.Request.Method == http.MethodGet {
+			req.Query, req.OperationName = c.Query("query"), c.Query("operationName")
+			if v := c.Query("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					c.Error(fmt.Errorf("invalid variables: %w", err)).SetType(gin.ErrorTypeBind)
+					return
+				}
+			}
+		} else if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+			return
+		}
+		if vr := graphql.ValidateDocument(&schema, doc, nil); !vr.IsValid {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: vr.Errors})
+			return
+		}
+		op := operation(doc, req.OperationName)
+		if op == nil {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(fmt.Errorf("unknown operation %q", req.OperationName))})
+			return
+		}
+		if op.Operation == ast.OperationTypeMutation && c.Request.Method == http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, &graphql.Result{Errors: gqlerrors.FormatErrors(fmt.Errorf("mutations must be sent by POST"))})
+			return
+		}
+		if op.Operation == ast.OperationTypeMutation && mutationToken == "" {
+			c.JSON(http.StatusForbidden, &graphql.Result{Errors: gqlerrors.FormatErrors(fmt.Errorf("mutations are disabled"))})
+			return
+		}
+		if op.Operation == ast.OperationTypeMutation && !bearerAuthorized(c.GetHeader("Authorization"), mutationToken) {
+			c.Header("WWW-Authenticate", `Bearer realm="graphql"`)
+			c.JSON(http.StatusUnauthorized, &graphql.Result{Errors: gqlerrors.FormatErrors(fmt.Errorf("mutations need the bearer token of the server"))})
+			return
+		}
+		limits := &queryLimits{fragments: fragments(doc), variables: req.Variables}
+		root := schema.QueryType()
+		if op.Operation == ast.OperationTypeMutation {
+			root = schema.MutationType()
+		}
+		depth, complexity := limits.measure(root, op.SelectionSet, 1)
+		if depth > maxDepth {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(fmt.Errorf("query depth %d exceeds the limit %d", depth, maxDepth))})
+			return
+		}
+		if complexity > maxComplexity {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(fmt.Errorf("query complexity %d exceeds the limit %d", complexity, maxComplexity))})
+			return
+		}
+		ctx := context.WithValue(c.Request.Context(), graphQLLoadersKey{}, newGraphQLLoaders())
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:        schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       ctx,
+		})
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// bearerAuthorized reports whether an Authorization header has the bearer token, never for a blank token.
+func bearerAuthorized(header, token string) bool {
+	got, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// operation finds the operation to execute, the only one of the document if name is blank.
+func operation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" && found != nil {
+			return nil
+		}
+		if name == "" || op.Name != nil && op.Name.Value == name {
+			found = op
+		}
+	}
+	return found
+}
+
+func fragments(doc *ast.Document) map[string]*ast.FragmentDefinition {
+	frags := map[string]*ast.FragmentDefinition{}
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			frags[frag.Name.Value] = frag
+		}
+	}
+	return frags
+}
+
+// queryLimits measures the depth and the complexity of a validated query.
+type queryLimits struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// measure returns the depth and the complexity of the selections on the parent type,
+// the introspection fields aren't counted.
+func (l *queryLimits) measure(parent *graphql.Object, set *ast.SelectionSet, depth int) (maxDepth, complexity int) {
+	if set == nil {
+		return depth - 1, 0
+	}
+	maxDepth = depth
+	for _, sel := range set.Selections {
+		var d, cost int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			def, ok := parent.Fields()[sel.Name.Value]
+			if !ok {
+				continue
+			}
+			child, isList := objectOf(def.Type)
+			d, cost = depth, 1
+			if child != nil {
+				var childCost int
+				d, childCost = l.measure(child, sel.SelectionSet, depth+1)
+				cost += childCost * l.listSize(sel, def, isList)
+			}
+		case *ast.InlineFragment:
+			d, cost = l.measure(parent, sel.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if frag := l.fragments[sel.Name.Value]; frag != nil {
+				d, cost = l.measure(parent, frag.SelectionSet, depth)
+			}
+		}
+		if d > maxDepth {
+			maxDepth = d
+		}
+		complexity += cost
+	}
+	return maxDepth, complexity
+}
+
+// listSize is the first argument of a field or its default value, else defaultListSize for a list.
+func (l *queryLimits) listSize(field *ast.Field, def *graphql.FieldDefinition, isList bool) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			if n, ok := l.variables[v.Name.Value].(float64); ok && n > 0 {
+				return int(n)
+			}
+		}
+	}
+	for _, arg := range def.Args {
+		if n, ok := arg.DefaultValue.(int); ok && arg.Name() == "first" {
+			return n
+		}
+	}
+	// the edges of a connection are counted by the first argument of the connection
+	if isList && field.Name.Value != "edges" {
+		return defaultListSize
+	}
+	return 1
+}
+
+// objectOf unwraps the non null and list types to an object type, nil for a scalar.
+func objectOf(t graphql.Type) (obj *graphql.Object, isList bool) {
+	for {
+		switch tt := t.(type) {
+		case *graphql.NonNull:
+			t = tt.OfType
+		case *graphql.List:
+			t, isList = tt.OfType, true
+		case *graphql.Object:
+			return tt, isList
+		default:
+			return nil, isList
+		}
+	}
+}
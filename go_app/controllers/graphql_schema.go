@@ -0,0 +1,517 @@
+package controllers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	m "go_app/src/models"
+)
+
+// graphQLError is an error of a resolver, the model errors are classified in its code extension
+// and the validation errors list the invalid fields, the other errors are hidden.
+type graphQLError struct {
+	message string
+	code    string
+	fields  []m.FieldError
+}
+
+func (e *graphQLError) Error() string { return e.message }
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e *graphQLError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.code}
+	if len(e.fields) > 0 {
+		ext["errors"] = e.fields
+	}
+	return ext
+}
+
+func newGraphQLError(err error) error {
+	var ve *m.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		return &graphQLError{message: err.Error(), code: "VALIDATION_FAILED", fields: ve.Errors}
+	case errors.Is(err, m.ErrValidation):
+		return &graphQLError{message: err.Error(), code: "VALIDATION_FAILED"}
+	case errors.Is(err, m.ErrNotFound):
+		return &graphQLError{message: err.Error(), code: "NOT_FOUND"}
+	case errors.Is(err, m.ErrDuplicate), errors.Is(err, m.ErrForeignKey):
+		return &graphQLError{message: err.Error(), code: "CONFLICT"}
+	}
+	log.Printf("GraphQL resolver error: %v\n", err)
+	return &graphQLError{message: "Internal Server Error", code: "INTERNAL"}
+}
+
+// batchLoader batches the loads of the keys of a request: graphql-go resolves the thunks
+// breadth first, so the keys loaded at a level of the query are fetched by a single call.
+type batchLoader[K comparable, V any] struct {
+	mu      sync.Mutex
+	fetch   func(ctx context.Context, keys []K) (map[K]V, error)
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	errs    map[K]error
+}
+
+func newBatchLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *batchLoader[K, V] {
+	return &batchLoader[K, V]{fetch: fetch, queued: map[K]bool{}, results: map[K]V{}, errs: map[K]error{}}
+}
+
+// load queues the key and returns a thunk of its value, nil if there's no value of the key.
+func (l *batchLoader[K, V]) load(ctx context.Context, key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.pending) > 0 {
+			keys := l.pending
+			l.pending = nil
+			results, err := l.fetch(ctx, keys)
+			for _, k := range keys {
+				if err != nil {
+					l.errs[k] = err
+				} else if v, ok := results[k]; ok {
+					l.results[k] = v
+				}
+			}
+		}
+		if err := l.errs[key]; err != nil {
+			return nil, newGraphQLError(err)
+		}
+		if v, ok := l.results[key]; ok {
+			return v, nil
+		}
+		return nil, nil
+	}
+}
+
+// graphQLLoaders are the dataloaders of a request.
+type graphQLLoaders struct {
+	users     *batchLoader[int64, m.User]
+	userPosts *batchLoader[int64, []m.Post]
+}
+
+type graphQLLoadersKey struct{}
+
+func newGraphQLLoaders() *graphQLLoaders {
+	return &graphQLLoaders{
+		users: newBatchLoader(func(ctx context.Context, ids []int64) (map[int64]m.User, error) {
+			users, err := m.FindUsersContext(ctx, ids...)
+			if err != nil {
+				return nil, err
+			}
+			byId := map[int64]m.User{}
+			for _, u := range users {
+				byId[u.Id] = u
+			}
+			return byId, nil
+		}),
+		userPosts: newBatchLoader(func(ctx context.Context, ids []int64) (map[int64][]m.Post, error) {
+			args := make([]interface{}, len(ids))
+			for i, id := range ids {
+				args[i] = id
+			}
+			where := fmt.Sprintf("user_id IN (?%s) ORDER BY id", strings.Repeat(",?", len(ids)-1))
+			posts, err := m.FindPostsWhereContext(ctx, where, args...)
+			if err != nil {
+				return nil, err
+			}
+			byUser := map[int64][]m.Post{}
+			for _, id := range ids {
+				byUser[id] = []m.Post{}
+			}
+			for _, p := range posts {
+				byUser[p.UserId.V] = append(byUser[p.UserId.V], p)
+			}
+			return byUser, nil
+		}),
+	}
+}
+
+func loadersOf(ctx context.Context) *graphQLLoaders {
+	if l, ok := ctx.Value(graphQLLoadersKey{}).(*graphQLLoaders); ok {
+		return l
+	}
+	return newGraphQLLoaders()
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// graphQLScalar maps the Go type of a column field to a GraphQL scalar, a Null is nullable.
+// It's nil for the association fields.
+func graphQLScalar(column string, t reflect.Type) (typ graphql.Output, nullable bool) {
+	if t.Kind() == reflect.Struct && strings.HasPrefix(t.Name(), "Null[") && t.Implements(valuerType) {
+		if v, ok := t.FieldByName("V"); ok {
+			typ, _ = graphQLScalar(column, v.Type)
+			return typ, true
+		}
+	}
+	switch {
+	case column == "id":
+		return graphql.ID, false
+	case t == timeType:
+		return graphql.DateTime, false
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return graphql.Int, false
+	case reflect.Float32, reflect.Float64:
+		return graphql.Float, false
+	case reflect.Bool:
+		return graphql.Boolean, false
+	case reflect.String:
+		return graphql.String, false
+	}
+	return nil, false
+}
+
+// graphQLName converts a column name to a field name, e.g. created_at to createdAt.
+func graphQLName(column string) string {
+	parts := strings.Split(column, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// graphQLFields generates the fields of the columns of a model struct, only of the columns
+// listed unless it's nil, its associations and the SensitiveColumns are left out.
+// The Null columns are nullable, the others non null.
+func graphQLFields(model interface{}, only []string) graphql.Fields {
+	t := reflect.TypeOf(model)
+	fields := graphql.Fields{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		col := f.Tag.Get("db")
+		if col == "" || col == "-" || m.SensitiveColumns[col] || only != nil && !slices.Contains(only, col) {
+			continue
+		}
+		typ, nullable := graphQLScalar(col, f.Type)
+		if typ == nil {
+			continue
+		}
+		if !nullable {
+			typ = graphql.NewNonNull(typ)
+		}
+		index := i
+		fields[graphQLName(col)] = &graphql.Field{
+			Type: typ,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				v := reflect.Indirect(reflect.ValueOf(p.Source)).Field(index)
+				if valuer, ok := v.Interface().(driver.Valuer); ok {
+					return valuer.Value()
+				}
+				return v.Interface(), nil
+			},
+		}
+	}
+	return fields
+}
+
+// graphQLInputFields generates the input fields of the writable columns of a model struct,
+// they're all optional, the attributes are validated by the model.
+func graphQLInputFields(model interface{}) (graphql.InputObjectConfigFieldMap, map[string]string) {
+	t := reflect.TypeOf(model)
+	fields := graphql.InputObjectConfigFieldMap{}
+	columns := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		col := f.Tag.Get("db")
+		switch col {
+		case "", "-", "id", "created_at", "updated_at":
+			continue
+		}
+		typ, _ := graphQLScalar(col, f.Type)
+		if typ == nil || m.SensitiveColumns[col] {
+			continue
+		}
+		fields[graphQLName(col)] = &graphql.InputObjectFieldConfig{Type: typ}
+		columns[graphQLName(col)] = col
+	}
+	return fields, columns
+}
+
+// graphQLAttributes converts an input object to the attributes map of a model.
+func graphQLAttributes(input map[string]interface{}, columns map[string]string) map[string]interface{} {
+	am := map[string]interface{}{}
+	for k, v := range input {
+		if col, ok := columns[k]; ok {
+			am[col] = v
+		}
+	}
+	return am
+}
+
+func graphQLID(p graphql.ResolveParams) (int64, error) {
+	id, err := strconv.ParseInt(fmt.Sprint(p.Args["id"]), 10, 64)
+	if err != nil {
+		return 0, &graphQLError{message: fmt.Sprintf("invalid id %q", p.Args["id"]), code: "BAD_USER_INPUT"}
+	}
+	return id, nil
+}
+
+// encodePostCursor is the cursor of a post in the posts connection, a PostPage token of its id.
+func encodePostCursor(id int64) string {
+	return (&m.PostPage{}).Token(id)
+}
+
+func decodePostCursor(cursor string) (int64, error) {
+	id, err := (&m.PostPage{}).ParseToken(cursor)
+	if err != nil {
+		return 0, &graphQLError{message: fmt.Sprintf("invalid cursor %q", cursor), code: "BAD_USER_INPUT"}
+	}
+	return id, nil
+}
+
+// postConnection is the result of the posts connection.
+type postConnection struct {
+	posts       []m.Post
+	hasNextPage bool
+	totalCount  int64
+}
+
+// maxConnectionFirst is the maximum of the first argument of the connections.
+const maxConnectionFirst = 100
+
+// newGraphQLSchema generates the schema of the Post and User models, with the mutations of
+// the posts if mutations.
+func newGraphQLSchema(mutations bool) (graphql.Schema, error) {
+	var postType, userType *graphql.Object
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			// the id and the userAttributes of JSON:API, the other columns are personal or devise data
+			fields := graphQLFields(m.User{}, append([]string{"id"}, userAttributes...))
+			fields["posts"] = &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					u := p.Source.(m.User)
+					return loadersOf(p.Context).userPosts.load(p.Context, u.Id), nil
+				},
+			}
+			return fields
+		}),
+	})
+	postType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			fields := graphQLFields(m.Post{}, nil)
+			fields["user"] = &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					post := p.Source.(m.Post)
+					if !post.UserId.Valid {
+						return nil, nil
+					}
+					return loadersOf(p.Context).users.load(p.Context, post.UserId.V), nil
+				},
+			}
+			return fields
+		}),
+	})
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+	postEdgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PostEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return encodePostCursor(p.Source.(m.Post).Id), nil
+				},
+			},
+			"node": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+	postConnectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PostConnection",
+		Fields: graphql.Fields{
+			"edges": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postEdgeType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*postConnection).posts, nil
+				},
+			},
+			"pageInfo": &graphql.Field{
+				Type: graphql.NewNonNull(pageInfoType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					conn := p.Source.(*postConnection)
+					info := map[string]interface{}{"hasNextPage": conn.hasNextPage, "endCursor": nil}
+					if len(conn.posts) > 0 {
+						info["endCursor"] = encodePostCursor(conn.posts[len(conn.posts)-1].Id)
+					}
+					return info, nil
+				},
+			},
+			"totalCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*postConnection).totalCount, nil
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": &graphql.Field{
+				Type: postType,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := graphQLID(p)
+					if err != nil {
+						return nil, err
+					}
+					post, err := m.FindPostContext(p.Context, id)
+					if errors.Is(err, m.ErrNotFound) {
+						return nil, nil
+					} else if err != nil {
+						return nil, newGraphQLError(err)
+					}
+					return *post, nil
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := graphQLID(p)
+					if err != nil {
+						return nil, err
+					}
+					return loadersOf(p.Context).users.load(p.Context, id), nil
+				},
+			},
+			// the posts ordered by id, paginated by a PostPage after the cursor
+			"posts": &graphql.Field{
+				Type: graphql.NewNonNull(postConnectionType),
+				Args: graphql.FieldConfigArgument{
+					"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+					"after": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					first, _ := p.Args["first"].(int)
+					if first <= 0 || first > maxConnectionFirst {
+						return nil, &graphQLError{message: fmt.Sprintf("first must be between 1 and %d", maxConnectionFirst), code: "BAD_USER_INPUT"}
+					}
+					var after int64
+					if cursor, ok := p.Args["after"].(string); ok && cursor != "" {
+						var err error
+						if after, err = decodePostCursor(cursor); err != nil {
+							return nil, err
+						}
+					}
+					// one more post tells if there's a next page
+					page := &m.PostPage{Order: []m.Sort{{Column: "id"}}, PerPage: first + 1, Context: p.Context}
+					posts, err := page.After(after)
+					if err != nil {
+						return nil, newGraphQLError(err)
+					}
+					conn := &postConnection{posts: posts, totalCount: page.TotalItems}
+					if len(posts) > first {
+						conn.posts, conn.hasNextPage = posts[:first], true
+					}
+					return conn, nil
+				},
+			},
+		},
+	})
+
+	postInputFields, postInputColumns := graphQLInputFields(m.Post{})
+	postInputType := graphql.NewInputObject(graphql.InputObjectConfig{Name: "PostInput", Fields: postInputFields})
+	// the post written by a mutation is read from the primary
+	findPost := func(ctx context.Context, id int64) (interface{}, error) {
+		post, err := m.FindPostContext(m.Primary(ctx), id)
+		if err != nil {
+			return nil, newGraphQLError(err)
+		}
+		return *post, nil
+	}
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					input, _ := p.Args["input"].(map[string]interface{})
+					id, err := m.CreatePost(graphQLAttributes(input, postInputColumns))
+					if err != nil {
+						return nil, newGraphQLError(err)
+					}
+					return findPost(p.Context, id)
+				},
+			},
+			"updatePost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := graphQLID(p)
+					if err != nil {
+						return nil, err
+					}
+					if _, err := m.FindPostContext(m.Primary(p.Context), id); err != nil {
+						return nil, newGraphQLError(err)
+					}
+					input, _ := p.Args["input"].(map[string]interface{})
+					if err := m.UpdatePost(id, graphQLAttributes(input, postInputColumns)); err != nil {
+						return nil, newGraphQLError(err)
+					}
+					return findPost(p.Context, id)
+				},
+			},
+			"destroyPost": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := graphQLID(p)
+					if err != nil {
+						return nil, err
+					}
+					if err := m.DestroyPost(id); err != nil {
+						return nil, newGraphQLError(err)
+					}
+					return true, nil
+				},
+			},
+		},
+	})
+	if !mutations {
+		return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+	}
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go_app/src/models/modeltest"
+)
+
+// graphQLPost posts a query to a GraphQL handler with an Authorization header if it's not blank.
+func graphQLPost(t *testing.T, handler gin.HandlerFunc, query, authorization string) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/graphql", handler)
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+func TestGraphQLMutationAuthorization(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "graphql@example.com")
+	mutation := `mutation { createPost(input: {title: "A GraphQL post", content: "Some post content here, long enough", userId: ` +
+		strconv.FormatInt(userId, 10) + `}) { id } }`
+
+	if code, body := graphQLPost(t, GraphQLHandler(10, 1000, ""), mutation, "Bearer "); code != http.StatusForbidden {
+		t.Errorf("mutation without a token on the server = %d %s, want 403", code, body)
+	}
+	handler := GraphQLHandler(10, 1000, "secret")
+	for _, authorization := range []string{"", "Bearer wrong", "secret"} {
+		if code, body := graphQLPost(t, handler, mutation, authorization); code != http.StatusUnauthorized {
+			t.Errorf("mutation with the Authorization %q = %d %s, want 401", authorization, code, body)
+		}
+	}
+	if code, body := graphQLPost(t, handler, mutation, "Bearer secret"); code != http.StatusOK || strings.Contains(body, "errors") {
+		t.Errorf("mutation with the token = %d %s, want 200", code, body)
+	}
+}
+
+func TestGraphQLUserFields(t *testing.T) {
+	code, body := graphQLPost(t, GraphQLHandler(10, 1000, ""), `{ __type(name: "User") { fields { name } } }`, "")
+	if code != http.StatusOK {
+		t.Fatalf("introspection = %d %s, want 200", code, body)
+	}
+	for _, field := range []string{"email", "signInCount", "resetPasswordSentAt", "currentSignInAt", "lastSignInAt",
+		"currentSignInIp", "lastSignInIp", "encryptedPassword", "resetPasswordToken"} {
+		if strings.Contains(body, `"`+field+`"`) {
+			t.Errorf("User fields %s have %s", body, field)
+		}
+	}
+	for _, field := range []string{"id", "role", "createdAt", "updatedAt", "posts"} {
+		if !strings.Contains(body, `"`+field+`"`) {
+			t.Errorf("User fields %s don't have %s", body, field)
+		}
+	}
+}
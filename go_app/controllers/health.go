@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	m "go_app/src/models"
+)
+
+// ComponentStatus is the status of a dependency checked by the readiness probe.
+type ComponentStatus struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// HealthStatus is the body of the health and readiness probes.
+type HealthStatus struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+// HealthzHandler reports the process is alive, it checks no dependency.
+func HealthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthStatus{Status: statusOK})
+}
+
+// ReadyzHandler returns a handler reporting whether the service can serve requests:
+// the database must answer a ping within timeout and, if checkSchema is set, be migrated
+// to the schema version the models were generated from. It responds 503 on a failure.
+func ReadyzHandler(timeout time.Duration, checkSchema bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		res := HealthStatus{Status: statusOK, Components: map[string]ComponentStatus{}}
+		check := func(name string, f func(context.Context) error) {
+			start := time.Now()
+			cs := ComponentStatus{Status: statusOK}
+			if err := f(ctx); err != nil {
+				cs.Status, cs.Error = statusFail, err.Error()
+				res.Status = statusFail
+			}
+			cs.Latency = time.Since(start).String()
+			res.Components[name] = cs
+		}
+		check("database", m.Ping)
+		if checkSchema && res.Status == statusOK {
+			check("schema", m.CheckSchemaVersion)
+		}
+		status := http.StatusOK
+		if res.Status != statusOK {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, res)
+	}
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+)
+
+func TestReadyzHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		checkSchema bool
+		// breaks the database opened by modeltest.Open
+		breakDB    func(t *testing.T)
+		code       int
+		components map[string]string
+		// in the error of the failed component
+		err string
+	}{
+		{"ready", true, nil, http.StatusOK, map[string]string{"database": statusOK, "schema": statusOK}, ""},
+		{"schema unchecked", false, nil, http.StatusOK, map[string]string{"database": statusOK}, ""},
+		{"database down", true, func(t *testing.T) {
+			if err := m.DB.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}, http.StatusServiceUnavailable, map[string]string{"database": statusFail}, "closed"},
+		{"schema mismatch", true, func(t *testing.T) {
+			if _, err := m.DB.Exec(`INSERT INTO schema_migrations (version) VALUES ('99991231235959')`); err != nil {
+				t.Fatal(err)
+			}
+		}, http.StatusServiceUnavailable, map[string]string{"database": statusOK, "schema": statusFail}, `"99991231235959"`},
+		{"schema mismatch unchecked", false, func(t *testing.T) {
+			if _, err := m.DB.Exec(`DELETE FROM schema_migrations`); err != nil {
+				t.Fatal(err)
+			}
+		}, http.StatusOK, map[string]string{"database": statusOK}, ""},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modeltest.Open(t)
+			if tt.breakDB != nil {
+				tt.breakDB(t)
+			}
+			r := gin.New()
+			r.GET("/readyz", ReadyzHandler(time.Second, tt.checkSchema))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.code {
+				t.Errorf("GET /readyz = %d, want %d", w.Code, tt.code)
+			}
+			var res HealthStatus
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Components) != len(tt.components) {
+				t.Errorf("components = %v, want %v", res.Components, tt.components)
+			}
+			for name, status := range tt.components {
+				cs := res.Components[name]
+				if cs.Status != status {
+					t.Errorf("%s status = %q, want %q", name, cs.Status, status)
+				}
+				if status == statusFail && !strings.Contains(cs.Error, tt.err) {
+					t.Errorf("%s error = %q, want %s in it", name, cs.Error, tt.err)
+				}
+			}
+		})
+	}
+}
@@ -2,30 +2,69 @@ package controllers
 
 import (
 	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
 
 	"github.com/gin-gonic/gin"
-	// you can import models
-	//m "../models"
+	m "go_app/src/models"
 )
 
-func HomeHandler(c *gin.Context) {
-	// you can use model functions to do CRUD
-	//
-	// user, _ := m.FindUser(1)
-	// u, err := json.Marshal(user)
-	// if err != nil {
-	// 	log.Printf("JSON encoding error: %v\n", err)
-	// 	u = []byte("Get data error!")
-	// }
+// BuildInfo is the version info of the running binary, shown by the welcome page and the page footers.
+type BuildInfo struct {
+	GoVersion     string
+	Platform      string
+	Version       string
+	Revision      string
+	RevisionTime  string
+	Modified      bool
+	GinVersion    string
+	SchemaVersion string
+}
 
-	type Envs struct {
-		GoOnRailsVer string
-		GolangVer    string
-	}
+var (
+	buildInfo     BuildInfo
+	buildInfoOnce sync.Once
+)
 
-	gorVer := "0.4.0"
-	golangVer := "go version go1.13.4 darwin/amd64"
+// GetBuildInfo reads the build info embedded by the Go toolchain, the VCS fields are blank
+// for a binary built out of a repository.
+func GetBuildInfo() BuildInfo {
+	buildInfoOnce.Do(func() {
+		buildInfo = BuildInfo{
+			GoVersion:     runtime.Version(),
+			Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+			Version:       "(devel)",
+			GinVersion:    gin.Version,
+			SchemaVersion: m.SchemaVersion,
+		}
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if info.Main.Version != "" {
+			buildInfo.Version = info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/gin-gonic/gin" {
+				buildInfo.GinVersion = dep.Version
+			}
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				buildInfo.Revision = s.Value
+			case "vcs.time":
+				buildInfo.RevisionTime = s.Value
+			case "vcs.modified":
+				buildInfo.Modified = s.Value == "true"
+			}
+		}
+	})
+	return buildInfo
+}
 
-	envs := Envs{GoOnRailsVer: gorVer, GolangVer: golangVer}
-	c.HTML(http.StatusOK, "index.tmpl", envs)
+// HomeHandler renders the welcome page with the versions of the app.
+func HomeHandler(c *gin.Context) {
+	c.HTML(http.StatusOK, "index.tmpl", GetBuildInfo())
 }
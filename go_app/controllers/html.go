@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/microcosm-cc/bluemonday"
+	m "go_app/src/models"
+)
+
+// siteName is the og:site_name of the pages.
+const siteName = "go_app"
+
+// HTMLRender renders the templates of a views directory, each page is parsed with the layouts
+// and the partials so the pages can define the same blocks, e.g. "content". A page using the
+// layout is {{ template "layout" . }} followed by its blocks, the others are full documents.
+type HTMLRender struct {
+	pages map[string]*template.Template
+}
+
+// htmlFuncs are the functions of the templates.
+var htmlFuncs = template.FuncMap{
+	"date":    func(t time.Time) string { return t.Format("January 2, 2006") },
+	"iso8601": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}
+
+// NewHTMLRender parses the pages of dir, named by their paths in it, e.g. "posts/show.tmpl",
+// with the templates of dir/layouts and dir/partials.
+func NewHTMLRender(dir string) (*HTMLRender, error) {
+	shared := []string{}
+	for _, sub := range []string{"layouts", "partials"} {
+		files, err := filepath.Glob(filepath.Join(dir, sub, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		shared = append(shared, files...)
+	}
+	r := &HTMLRender{pages: map[string]*template.Template{}}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name, _ := filepath.Rel(dir, path)
+		name = filepath.ToSlash(name)
+		if info.IsDir() {
+			if name == "layouts" || name == "partials" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".tmpl" {
+			return nil
+		}
+		t, err := template.New(filepath.Base(path)).Funcs(htmlFuncs).ParseFiles(append(shared, path)...)
+		if err != nil {
+			return fmt.Errorf("Parse template %s error: %w", name, err)
+		}
+		r.pages[name] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Instance implements render.HTMLRender.
+func (r *HTMLRender) Instance(name string, data interface{}) render.Render {
+	t, ok := r.pages[name]
+	if !ok {
+		// fails with an unknown template error
+		return render.HTML{Template: template.New(name), Data: data}
+	}
+	return render.HTML{Template: t, Name: filepath.Base(name), Data: data}
+}
+
+// wantsHTMLPage reports whether the client asks for an HTML page, i.e. it's a browser or a crawler.
+func wantsHTMLPage(c *gin.Context) bool {
+	for _, accept := range strings.Split(c.GetHeader("Accept"), ",") {
+		if strings.TrimSpace(strings.SplitN(accept, ";", 2)[0]) == "text/html" {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlPage is the data of the layout: the title, the SEO and Open Graph meta tags and the
+// build info of the footer. The article times are only set for the post pages.
+type htmlPage struct {
+	SiteName    string
+	Title       string
+	Description string
+	URL         string
+	Type        string
+	Published   time.Time
+	Modified    time.Time
+	Build       BuildInfo
+}
+
+func newHTMLPage(c *gin.Context, title, description string) htmlPage {
+	return htmlPage{
+		SiteName:    siteName,
+		Title:       title,
+		Description: description,
+		URL:         absoluteURL(c.Request.URL.RequestURI()),
+		Type:        "website",
+		Build:       GetBuildInfo(),
+	}
+}
+
+// BaseURL is the scheme and host of the absolute URLs of the pages, e.g. the canonical and
+// Open Graph URLs, without a trailing slash. The Host header of a request isn't trusted for them.
+var BaseURL = "http://localhost:4000"
+
+// absoluteURL is the URL of a path on the BaseURL.
+func absoluteURL(path string) string {
+	return BaseURL + path
+}
+
+// stripTags is the plain text of a rendered content.
+var stripTags = bluemonday.StrictPolicy()
+
+// excerpt is the plain text of the rendered content of a post cut to about n characters,
+// for the descriptions of the pages and the posts index.
+func excerpt(p *m.Post, n int) (string, error) {
+	if !p.Content.Valid {
+		return "", nil
+	}
+	rendered, err := contentHTML(p)
+	if err != nil {
+		return "", err
+	}
+	text := strings.Join(strings.Fields(html.UnescapeString(stripTags.Sanitize(rendered))), " ")
+	if utf8.RuneCountInString(text) <= n {
+		return text, nil
+	}
+	runes := []rune(text)[:n]
+	if i := strings.LastIndex(string(runes), " "); i > 0 {
+		return string(runes)[:i] + "…", nil
+	}
+	return string(runes) + "…", nil
+}
+
+// postItem is a post of the posts index page.
+type postItem struct {
+	m.Post
+	Excerpt string
+}
+
+type postsIndexPage struct {
+	htmlPage
+	Posts []postItem
+	Total int64
+	// the links of the pagination, blank if there's no such page
+	First string
+	Next  string
+}
+
+type postShowPage struct {
+	htmlPage
+	Post    m.Post
+	Content template.HTML
+}
+
+// htmlIndex renders the posts index page, newest first and paginated by the param after,
+// the id of the last post of the previous page.
+func htmlIndex(c *gin.Context) {
+	var after int64
+	if a := c.Query("after"); a != "" {
+		var err error
+		if after, err = ToInt(a); err != nil || after < 0 {
+			c.Error(fmt.Errorf("invalid after %q", a)).SetType(gin.ErrorTypeBind)
+			return
+		}
+	}
+	// a post more tells whether there's a next page
+	const size = 10
+	page := &m.PostPage{Order: []m.Sort{{Column: "id", Desc: true}}, PerPage: size + 1, Context: c.Request.Context()}
+	posts, err := page.After(after)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	next := len(posts) > size
+	if next {
+		posts = posts[:size]
+	}
+	data := postsIndexPage{
+		htmlPage: newHTMLPage(c, "Posts", fmt.Sprintf("The %d posts of %s.", page.TotalItems, siteName)),
+		Total:    page.TotalItems,
+	}
+	for i := range posts {
+		text, err := excerpt(&posts[i], 200)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		data.Posts = append(data.Posts, postItem{Post: posts[i], Excerpt: text})
+	}
+	// the canonical URL of a page drops its other params
+	path := c.Request.URL.Path
+	data.URL = absoluteURL(path)
+	if after != 0 {
+		data.First = path
+		data.URL = absoluteURL(path + "?after=" + strconv.FormatInt(after, 10))
+	}
+	if next {
+		data.Next = path + "?" + url.Values{"after": {strconv.FormatInt(posts[size-1].Id, 10)}}.Encode()
+	}
+	c.HTML(http.StatusOK, "posts/index.tmpl", data)
+}
+
+// htmlShow renders the page of a post, an article for the Open Graph tags.
+func htmlShow(c *gin.Context, post *m.Post) {
+	description, err := excerpt(post, 160)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	rendered := ""
+	if post.Content.Valid {
+		if rendered, err = contentHTML(post); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	data := postShowPage{
+		htmlPage: newHTMLPage(c, post.Title.V, description),
+		Post:     *post,
+		// sanitized by renderMarkdown
+		Content: template.HTML(rendered),
+	}
+	data.URL = absoluteURL("/posts/" + strconv.FormatInt(post.Id, 10))
+	data.Type = "article"
+	data.Published, data.Modified = post.CreatedAt, post.UpdatedAt
+	c.HTML(http.StatusOK, "posts/show.tmpl", data)
+}
+
+// errorPage is the data of the error page of the browsers.
+type errorPage struct {
+	htmlPage
+	Problem Problem
+}
+
+func renderHTMLError(c *gin.Context, p Problem) {
+	data := errorPage{htmlPage: newHTMLPage(c, p.Title, p.Detail), Problem: p}
+	c.HTML(p.Status, "error.tmpl", data)
+}
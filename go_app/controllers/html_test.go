@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go_app/src/models/modeltest"
+)
+
+// getHTMLIndex renders the posts index page for a browser on the host evil.example.
+func getHTMLIndex(t *testing.T) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	render, err := NewHTMLRender("../views")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.HTMLRender = render
+	r.GET("/posts", IndexHandler)
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Host = "evil.example"
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /posts = %d %s, want 200", w.Code, w.Body.String())
+	}
+	return w.Body.String()
+}
+
+func TestHTMLIndex(t *testing.T) {
+	modeltest.Open(t)
+	prevBaseURL := BaseURL
+	BaseURL = "https://example.com"
+	t.Cleanup(func() { BaseURL = prevBaseURL })
+	userId := modeltest.CreateUser(t, "html@example.com")
+	for i := 0; i < 10; i++ {
+		modeltest.CreatePost(t, userId, "A post of the index", "Some post content here, long enough")
+	}
+
+	body := getHTMLIndex(t)
+	if !strings.Contains(body, `href="https://example.com/posts"`) || strings.Contains(body, "evil.example") {
+		t.Errorf("canonical URL of the index isn't on the BaseURL:\n%s", body)
+	}
+	if strings.Contains(body, `rel="next"`) {
+		t.Errorf("a full last page has a next link:\n%s", body)
+	}
+
+	modeltest.CreatePost(t, userId, "A post of the index", "Some post content here, long enough")
+	if body := getHTMLIndex(t); !strings.Contains(body, `href="/posts?after=2" rel="next"`) {
+		t.Errorf("the first of two pages has no next link after the post 2:\n%s", body)
+	}
+}
@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	m "go_app/src/models"
+)
+
+// JSONAPIMediaType is the media type of the JSON:API documents, the handlers respond with
+// a document when the client accepts it.
+const JSONAPIMediaType = "application/vnd.api+json"
+
+// JSONAPIDocument is the top level of a JSON:API response, Data is a resource, a list of resources or nil.
+type JSONAPIDocument struct {
+	Data     interface{}            `json:"data"`
+	Included []JSONAPIResource      `json:"included,omitempty"`
+	Links    map[string]string      `json:"links,omitempty"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+}
+
+// JSONAPIResource is a resource object, the attributes are the columns of a model
+// but its id, foreign keys and SensitiveColumns, only the userAttributes for a user.
+type JSONAPIResource struct {
+	Type          string                         `json:"type"`
+	ID            string                         `json:"id"`
+	Attributes    map[string]json.RawMessage     `json:"attributes"`
+	Relationships map[string]JSONAPIRelationship `json:"relationships,omitempty"`
+	Links         map[string]string              `json:"links,omitempty"`
+}
+
+// JSONAPIIdentifier is a resource identifier object.
+type JSONAPIIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// JSONAPIRelationship is a relationship object, Data is an identifier or nil for a to-one
+// relationship and a list of identifiers for a to-many one.
+type JSONAPIRelationship struct {
+	Data interface{} `json:"data"`
+}
+
+// JSONAPIError is an error object of a JSON:API error response.
+type JSONAPIError struct {
+	Status string              `json:"status"`
+	Title  string              `json:"title"`
+	Detail string              `json:"detail,omitempty"`
+	Source *JSONAPIErrorSource `json:"source,omitempty"`
+	Meta   map[string]string   `json:"meta,omitempty"`
+}
+
+// JSONAPIErrorSource points to the attribute of an invalid field.
+type JSONAPIErrorSource struct {
+	Pointer string `json:"pointer"`
+}
+
+// wantsJSONAPI reports whether the client accepts JSON:API documents.
+func wantsJSONAPI(c *gin.Context) bool {
+	for _, accept := range strings.Split(c.GetHeader("Accept"), ",") {
+		if strings.TrimSpace(strings.SplitN(accept, ";", 2)[0]) == JSONAPIMediaType {
+			return true
+		}
+	}
+	return false
+}
+
+func renderJSONAPI(c *gin.Context, status int, v interface{}) {
+	c.Header("Content-Type", JSONAPIMediaType)
+	c.JSON(status, v)
+}
+
+// renderJSONAPIErrors renders a problem as JSON:API error objects, one per invalid field.
+func renderJSONAPIErrors(c *gin.Context, p Problem) {
+	status := strconv.Itoa(p.Status)
+	meta := map[string]string(nil)
+	if p.RequestID != "" {
+		meta = map[string]string{"request_id": p.RequestID}
+	}
+	errs := []JSONAPIError{}
+	for _, fe := range p.Errors {
+		errs = append(errs, JSONAPIError{Status: status, Title: p.Title, Detail: fe.Message, Meta: meta,
+			Source: &JSONAPIErrorSource{Pointer: "/data/attributes/" + fe.Field}})
+	}
+	if len(errs) == 0 {
+		errs = append(errs, JSONAPIError{Status: status, Title: p.Title, Detail: p.Detail, Meta: meta})
+	}
+	renderJSONAPI(c, p.Status, gin.H{"errors": errs})
+}
+
+// resourceAttributes is the JSON object of a model record without the excluded keys.
+func resourceAttributes(record interface{}, exclude ...string) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	attrs := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &attrs); err != nil {
+		return nil, err
+	}
+	for _, k := range exclude {
+		delete(attrs, k)
+	}
+	for k := range m.SensitiveColumns {
+		delete(attrs, k)
+	}
+	return attrs, nil
+}
+
+// postResource serializes a post, only the attributes in fields are kept unless it's empty.
+func postResource(p m.Post, fields []string) (JSONAPIResource, error) {
+	attrs, err := resourceAttributes(p, "id", "user_id", "user")
+	if err != nil {
+		return JSONAPIResource{}, err
+	}
+	if len(fields) > 0 {
+		attrs = onlyAttributes(attrs, fields)
+	}
+	user := JSONAPIRelationship{}
+	if p.UserId.Valid {
+		user.Data = JSONAPIIdentifier{Type: "users", ID: strconv.FormatInt(p.UserId.V, 10)}
+	}
+	id := strconv.FormatInt(p.Id, 10)
+	return JSONAPIResource{
+		Type:          "posts",
+		ID:            id,
+		Attributes:    attrs,
+		Relationships: map[string]JSONAPIRelationship{"user": user},
+		Links:         map[string]string{"self": "/posts/" + id},
+	}, nil
+}
+
+// userAttributes are the attributes of a user resource, the other columns, e.g. the email,
+// the sign in IPs and counts, are personal or devise data.
+var userAttributes = []string{"role", "created_at", "updated_at"}
+
+// onlyAttributes keeps the attributes of names.
+func onlyAttributes(attrs map[string]json.RawMessage, names []string) map[string]json.RawMessage {
+	kept := map[string]json.RawMessage{}
+	for _, name := range names {
+		if v, ok := attrs[name]; ok {
+			kept[name] = v
+		}
+	}
+	return kept
+}
+
+// userResource serializes a user with its preloaded posts, only its userAttributes.
+func userResource(u m.User) (JSONAPIResource, error) {
+	attrs, err := resourceAttributes(u)
+	if err != nil {
+		return JSONAPIResource{}, err
+	}
+	attrs = onlyAttributes(attrs, userAttributes)
+	posts := []JSONAPIIdentifier{}
+	for _, p := range u.Posts {
+		posts = append(posts, JSONAPIIdentifier{Type: "posts", ID: strconv.FormatInt(p.Id, 10)})
+	}
+	return JSONAPIResource{
+		Type:          "users",
+		ID:            strconv.FormatInt(u.Id, 10),
+		Attributes:    attrs,
+		Relationships: map[string]JSONAPIRelationship{"posts": {Data: posts}},
+	}, nil
+}
+
+// jsonAPIParams reads the fields[posts] and include params, only the user of the posts can be included.
+func jsonAPIParams(query url.Values) (fields []string, includeUser bool, err error) {
+	if f := query.Get("fields[posts]"); f != "" {
+		if fields, err = parseFields(f, m.PostRepository.Columns()); err != nil {
+			return nil, false, err
+		}
+	}
+	for _, inc := range strings.Split(query.Get("include"), ",") {
+		switch strings.TrimSpace(inc) {
+		case "":
+		case "user":
+			includeUser = true
+		default:
+			return nil, false, fmt.Errorf("unsupported include %q", inc)
+		}
+	}
+	return fields, includeUser, nil
+}
+
+// jsonAPIPostsDocument serializes the posts, with their users in included if includeUser.
+// The users are preloaded with their posts by UserIncludesWhere.
+func jsonAPIPostsDocument(ctx context.Context, posts []m.Post, fields []string, includeUser bool) (*JSONAPIDocument, []JSONAPIResource, error) {
+	data := []JSONAPIResource{}
+	userIds := []interface{}{}
+	seen := map[int64]bool{}
+	for _, p := range posts {
+		r, err := postResource(p, fields)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = append(data, r)
+		if p.UserId.Valid && !seen[p.UserId.V] {
+			seen[p.UserId.V] = true
+			userIds = append(userIds, p.UserId.V)
+		}
+	}
+	doc := &JSONAPIDocument{}
+	if includeUser && len(userIds) > 0 {
+		where := fmt.Sprintf("id IN (?%s)", strings.Repeat(",?", len(userIds)-1))
+		users, err := m.UserIncludesWhereContext(ctx, []string{"posts"}, where, userIds...)
+		if err != nil && !errors.Is(err, m.ErrNotFound) {
+			return nil, nil, err
+		}
+		doc.Included = []JSONAPIResource{}
+		for _, u := range users {
+			r, err := userResource(u)
+			if err != nil {
+				return nil, nil, err
+			}
+			doc.Included = append(doc.Included, r)
+		}
+	}
+	return doc, data, nil
+}
+
+// jsonAPIIndex lists the posts as a JSON:API document paginated by a PostPage on the ids,
+// with the params filter, sort=id or -id, page[size], page[after], fields[posts] and include=user.
+// The contents are rendered to HTML if html.
+func jsonAPIIndex(c *gin.Context, html bool) {
+	query := c.Request.URL.Query()
+	fields, includeUser, err := jsonAPIParams(query)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	where, args, err := m.PostRepository.WhereClause(parseFilters(query), nil)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	desc := false
+	switch query.Get("sort") {
+	case "", "id":
+	case "-id":
+		desc = true
+	default:
+		c.Error(fmt.Errorf("unsupported sort %q, the pages are ordered by id or -id", query.Get("sort"))).SetType(gin.ErrorTypeBind)
+		return
+	}
+	size, after, err := parsePage(query)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	// a post more tells whether there's a next page
+	page := &m.PostPage{WhereString: where, WhereParams: args, Order: []m.Sort{{Column: "id", Desc: desc}}, PerPage: size + 1, Context: c.Request.Context()}
+	posts, err := page.After(after)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	next := len(posts) > size
+	if next {
+		posts = posts[:size]
+	}
+	if html {
+		if err := renderContents(posts); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	doc, data, err := jsonAPIPostsDocument(c.Request.Context(), posts, fields, includeUser)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	doc.Data = data
+	totalPages := (page.TotalItems + int64(size) - 1) / int64(size)
+	doc.Meta = map[string]interface{}{"total": page.TotalItems, "total_pages": totalPages, "per_page": size}
+	doc.Links = map[string]string{"self": c.Request.URL.RequestURI()}
+	query.Del("page[after]")
+	doc.Links["first"] = pageLink(c.Request.URL.Path, query)
+	if next {
+		query.Set("page[after]", strconv.FormatInt(posts[size-1].Id, 10))
+		doc.Links["next"] = pageLink(c.Request.URL.Path, query)
+	}
+	renderJSONAPI(c, http.StatusOK, doc)
+}
+
+func pageLink(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+// jsonAPIShow renders a post as a JSON:API document, with the params fields[posts] and include=user.
+func jsonAPIShow(c *gin.Context, post *m.Post) {
+	fields, includeUser, err := jsonAPIParams(c.Request.URL.Query())
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	doc, data, err := jsonAPIPostsDocument(c.Request.Context(), []m.Post{*post}, fields, includeUser)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	doc.Data = data[0]
+	doc.Links = map[string]string{"self": c.Request.URL.RequestURI()}
+	renderJSONAPI(c, http.StatusOK, doc)
+}
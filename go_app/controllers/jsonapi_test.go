@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+)
+
+func TestUserResourceAttributes(t *testing.T) {
+	u := m.User{
+		Id:              3,
+		Email:           "user@example.com",
+		SignInCount:     5,
+		CurrentSignInIp: m.Null[string]{V: "192.0.2.1", Valid: true},
+		LastSignInIp:    m.Null[string]{V: "192.0.2.2", Valid: true},
+		Role:            m.Null[string]{V: "admin", Valid: true},
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	r, err := userResource(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for name := range r.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := "[created_at role updated_at]"; fmt.Sprint(names) != want {
+		t.Errorf("user attributes = %v, want %s", names, want)
+	}
+}
+
+// getJSONAPIIndex gets the posts index as a JSON:API document.
+func getJSONAPIIndex(t *testing.T, target string) JSONAPIDocument {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/posts", IndexHandler)
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Accept", JSONAPIMediaType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d %s, want 200", target, w.Code, w.Body.String())
+	}
+	var doc JSONAPIDocument
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestJSONAPINextLink(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "jsonapi@example.com")
+	for i := 0; i < 2; i++ {
+		modeltest.CreatePost(t, userId, "A post of the index", "Some post content here, long enough")
+	}
+
+	doc := getJSONAPIIndex(t, "/posts?page%5Bsize%5D=2")
+	if next, ok := doc.Links["next"]; ok {
+		t.Errorf("a full last page has a next link %q", next)
+	}
+	if got := fmt.Sprint(doc.Meta["total_pages"], " ", doc.Meta["per_page"]); got != "1 2" {
+		t.Errorf("total_pages and per_page = %s, want 1 2", got)
+	}
+
+	modeltest.CreatePost(t, userId, "A post of the index", "Some post content here, long enough")
+	doc = getJSONAPIIndex(t, "/posts?page%5Bsize%5D=2")
+	if want := "/posts?page%5Bafter%5D=2&page%5Bsize%5D=2"; doc.Links["next"] != want {
+		t.Errorf("next link of the first of two pages = %q, want %q", doc.Links["next"], want)
+	}
+	if got := fmt.Sprint(doc.Meta["total_pages"]); got != "2" {
+		t.Errorf("total_pages = %s, want 2", got)
+	}
+}
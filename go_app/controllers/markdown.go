@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	m "go_app/src/models"
+)
+
+// MarkdownCacheSize is the maximum count of rendered post contents kept in memory,
+// the least recently used one is dropped when it's exceeded.
+var MarkdownCacheSize = 1000
+
+// markdown renders CommonMark with the GFM extensions: tables, strikethrough, autolinks and task lists.
+// The raw HTML of the posts is rendered too, it's left to the sanitizer.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// markdownPolicy keeps the HTML of the user generated content, i.e. no script, style, iframe,
+// event handler or javascript: URL, plus the language classes of the code blocks, the
+// alignment of the table cells and the checkboxes of the task lists.
+var markdownPolicy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	p.AllowStyles("text-align").MatchingEnum("left", "center", "right").OnElements("th", "td")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}()
+
+// renderMarkdown renders Markdown to sanitized HTML.
+func renderMarkdown(src string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(src), &buf); err != nil {
+		return "", fmt.Errorf("Render Markdown error: %w", err)
+	}
+	return markdownPolicy.Sanitize(buf.String()), nil
+}
+
+// renderedContent is the HTML of a Markdown source, by its SHA-256.
+type renderedContent struct {
+	sum  [sha256.Size]byte
+	html string
+}
+
+var markdownCache = struct {
+	sync.Mutex
+	lru   *list.List
+	items map[[sha256.Size]byte]*list.Element
+}{lru: list.New(), items: map[[sha256.Size]byte]*list.Element{}}
+
+// contentHTML renders the content of a post, the HTML is cached by the hash of the Markdown
+// so an updated content is rendered again whatever the precision of updated_at.
+func contentHTML(p *m.Post) (string, error) {
+	sum := sha256.Sum256([]byte(p.Content.V))
+	markdownCache.Lock()
+	if el, ok := markdownCache.items[sum]; ok {
+		markdownCache.lru.MoveToFront(el)
+		rendered := el.Value.(*renderedContent).html
+		markdownCache.Unlock()
+		return rendered, nil
+	}
+	markdownCache.Unlock()
+
+	rendered, err := renderMarkdown(p.Content.V)
+	if err != nil {
+		return "", err
+	}
+
+	markdownCache.Lock()
+	defer markdownCache.Unlock()
+	if el, ok := markdownCache.items[sum]; ok {
+		// rendered concurrently by another request
+		markdownCache.lru.MoveToFront(el)
+		return rendered, nil
+	}
+	markdownCache.items[sum] = markdownCache.lru.PushFront(&renderedContent{sum: sum, html: rendered})
+	for markdownCache.lru.Len() > MarkdownCacheSize {
+		el := markdownCache.lru.Back()
+		markdownCache.lru.Remove(el)
+		delete(markdownCache.items, el.Value.(*renderedContent).sum)
+	}
+	return rendered, nil
+}
+
+// contentFormat reads the format param of the post contents: "markdown", the default, for the
+// raw content or "html" for the rendered one.
+func contentFormat(format string) (html bool, err error) {
+	switch format {
+	case "", "markdown":
+		return false, nil
+	case "html":
+		return true, nil
+	}
+	return false, fmt.Errorf("unsupported format %q, it's markdown or html", format)
+}
+
+// renderContent replaces the Markdown content of a post with its HTML, a NULL content is kept.
+func renderContent(p *m.Post) error {
+	if !p.Content.Valid {
+		return nil
+	}
+	rendered, err := contentHTML(p)
+	if err != nil {
+		return err
+	}
+	p.Content = m.NewNull(rendered)
+	return nil
+}
+
+func renderContents(posts []m.Post) error {
+	for i := range posts {
+		if err := renderContent(&posts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	m "go_app/src/models"
+)
+
+// TestContentHTMLUpdated checks an updated content is rendered again, even at the same updated_at
+// as MySQL keeps it to the second.
+func TestContentHTMLUpdated(t *testing.T) {
+	updatedAt := time.Now().Truncate(time.Second)
+	for _, content := range []string{"*first*", "**second**"} {
+		p := &m.Post{Id: 1, Content: m.NewNull(content), UpdatedAt: updatedAt}
+		rendered, err := contentHTML(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := strings.Trim(content, "*")
+		if !strings.Contains(rendered, want) {
+			t.Errorf("contentHTML(%q) = %q, want the %s content", content, rendered, want)
+		}
+	}
+}
+
+func TestRenderMarkdownSanitized(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    []string
+		notWant []string
+	}{
+		{"script", "Hello<script>alert(1)</script>", []string{"Hello"}, []string{"<script", "alert(1)"}},
+		{"javascript link", "[click](javascript:alert(1))", []string{"click"}, []string{"javascript:"}},
+		{"javascript href", `<a href="javascript:alert(1)">click</a>`, []string{"click"}, []string{"javascript:"}},
+		{"onerror", `<img src="x.png" onerror="alert(1)">`, []string{`src="x.png"`}, []string{"onerror", "alert(1)"}},
+		{"code", "```go\nfmt.Println(\"<b>\")\n```", []string{"<pre><code", "fmt.Println(&#34;&lt;b&gt;&#34;)"}, nil},
+		{"inline code", "call `x < y`", []string{"<code>x &lt; y</code>"}, nil},
+		{"table", "| a | b |\n|---|---|\n| 1 | 2 |", []string{"<table>", "<th>a</th>", "<td>2</td>"}, nil},
+		{"link", "[site](https://example.com)", []string{`href="https://example.com"`, `rel="nofollow"`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderMarkdown(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("renderMarkdown(%q) = %q, want %q in it", tt.src, got, s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("renderMarkdown(%q) = %q, want no %q in it", tt.src, got, s)
+				}
+			}
+		})
+	}
+}
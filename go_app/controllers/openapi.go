@@ -0,0 +1,677 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	m "go_app/src/models"
+)
+
+// OpenAPIDocument is an OpenAPI 3.0 document of the routes of the app.
+type OpenAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       OpenAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                      `json:"components"`
+}
+
+// OpenAPIInfo is the info object of a document.
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIComponents holds the schemas referenced by the operations, e.g. #/components/schemas/Post.
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas"`
+}
+
+// OpenAPIOperation is an operation of a path, i.e. a route.
+type OpenAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	OperationID string                     `json:"operationId,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter is a path or query parameter of an operation.
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Style       string         `json:"style,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+	Example     interface{}    `json:"example,omitempty"`
+}
+
+// OpenAPIRequestBody is the body of an operation, by media type.
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an operation, by media type.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the schema of a body.
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPISchema is a schema object, AdditionalProperties is a schema or false.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties interface{}               `json:"additionalProperties,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	MinLength            *int64                    `json:"minLength,omitempty"`
+	MaxLength            *int64                    `json:"maxLength,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+}
+
+// openAPIRoute documents the routes of a handler, the routes of the other handlers only get
+// a generic 200 response in the document.
+type openAPIRoute struct {
+	summary     string
+	description string
+	parameters  []OpenAPIParameter
+	body        *OpenAPIRequestBody
+	responses   map[string]OpenAPIResponse
+}
+
+// openAPIModels are the types with a component schema, the models have their constraints
+// from the valid tags.
+var openAPIModels = map[reflect.Type]string{
+	reflect.TypeOf(m.Post{}):          "Post",
+	reflect.TypeOf(m.User{}):          "User",
+	reflect.TypeOf(m.PostHit{}):       "PostHit",
+	reflect.TypeOf(m.FieldError{}):    "FieldError",
+	reflect.TypeOf(Problem{}):         "Problem",
+	reflect.TypeOf(HealthStatus{}):    "HealthStatus",
+	reflect.TypeOf(JSONAPIDocument{}): "JSONAPIDocument",
+}
+
+// the schemas of the bodies shared by the routes
+func schemaRef(name string) *OpenAPISchema {
+	return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func dataSchema(data *OpenAPISchema, extra map[string]*OpenAPISchema) *OpenAPISchema {
+	props := map[string]*OpenAPISchema{"data": data}
+	for k, v := range extra {
+		props[k] = v
+	}
+	required := []string{}
+	for k := range props {
+		required = append(required, k)
+	}
+	sort.Strings(required)
+	return &OpenAPISchema{Type: "object", Properties: props, Required: required, AdditionalProperties: false}
+}
+
+func jsonResponse(description string, schema *OpenAPISchema) OpenAPIResponse {
+	return OpenAPIResponse{Description: description, Content: map[string]OpenAPIMediaType{"application/json": {Schema: schema}}}
+}
+
+func problemResponse(description string) OpenAPIResponse {
+	return OpenAPIResponse{Description: description, Content: map[string]OpenAPIMediaType{
+		"application/problem+json": {Schema: schemaRef("Problem")},
+		JSONAPIMediaType:           {Schema: &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{"errors": {Type: "array", Items: &OpenAPISchema{Type: "object"}}}}},
+		"text/html":                {Schema: stringSchema},
+	}}
+}
+
+func htmlResponse(description string) OpenAPIResponse {
+	return OpenAPIResponse{Description: description, Content: map[string]OpenAPIMediaType{"text/html": {Schema: stringSchema}}}
+}
+
+// withHTMLPage adds the server-rendered page of the browsers to the media types of a response.
+func withHTMLPage(r OpenAPIResponse) OpenAPIResponse {
+	r.Content["text/html"] = OpenAPIMediaType{Schema: stringSchema}
+	return r
+}
+
+// withJSONAPI adds the JSON:API document to the media types of a response.
+func withJSONAPI(r OpenAPIResponse) OpenAPIResponse {
+	r.Content[JSONAPIMediaType] = OpenAPIMediaType{Schema: schemaRef("JSONAPIDocument")}
+	return r
+}
+
+func queryParam(name, description string, schema *OpenAPISchema) OpenAPIParameter {
+	return OpenAPIParameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+var (
+	formatParam   = queryParam("format", "Format of the post contents, the Markdown or its sanitized HTML", &OpenAPISchema{Type: "string", Enum: []string{"markdown", "html"}})
+	stringSchema  = &OpenAPISchema{Type: "string"}
+	integerSchema = &OpenAPISchema{Type: "integer", Format: "int64"}
+	idParam       = OpenAPIParameter{Name: "id", In: "path", Required: true, Schema: integerSchema, Example: 1}
+)
+
+// openAPIRoutes documents the handlers by their function names.
+var openAPIRoutes = map[string]openAPIRoute{
+	"IndexHandler": {
+		summary: "List the posts",
+		description: "The pages are keyed on the ids: only the posts sorted by id or -id are paginated by page[after], " +
+			"with another sort only the first page is listed and next_after is 0.",
+		parameters: []OpenAPIParameter{
+			{Name: "filter", In: "query", Style: "deepObject", Description: "Filters on the columns, e.g. filter[user_id]=3 or filter[created_at][gte]=2024-01-01",
+				Schema: &OpenAPISchema{Type: "object", AdditionalProperties: stringSchema}},
+			queryParam("sort", "Comma separated columns, descending with a -, e.g. -created_at,title. Only id or -id can be paginated by page[after]", stringSchema),
+			queryParam("fields", "Comma separated columns of the posts to render, e.g. id,title", stringSchema),
+			queryParam("page[size]", "Page size, 10 by default and at most 100", integerSchema),
+			queryParam("page[after]", "Id of the last post of the previous page, the posts must be sorted by id or -id, a sort on other columns isn't paginated", integerSchema),
+			queryParam("include", "Related resources of a JSON:API document, only user", stringSchema),
+			queryParam("after", "Id of the last post of the previous page of the HTML page", integerSchema),
+			formatParam,
+		},
+		responses: map[string]OpenAPIResponse{
+			"200": withHTMLPage(withJSONAPI(jsonResponse("A page of the posts, only with the columns of the fields param if it's given, or the page of the posts paginated by after",
+				dataSchema(&OpenAPISchema{Type: "array", Items: schemaRef("Post")},
+					map[string]*OpenAPISchema{"next_after": {Type: "integer", Format: "int64", Description: "page[after] of the next page, 0 on the last page or if the posts aren't sorted by id"}})))),
+			"400": problemResponse("Invalid filter, sort, fields, page or after param"),
+		},
+	},
+	"ShowHandler": {
+		summary:    "Get a post",
+		parameters: []OpenAPIParameter{idParam, queryParam("include", "Related resources of a JSON:API document, only user", stringSchema), formatParam},
+		responses: map[string]OpenAPIResponse{
+			"200": withHTMLPage(withJSONAPI(jsonResponse("The post, or its page", dataSchema(schemaRef("Post"), nil)))),
+			"400": problemResponse("Invalid id"),
+			"404": problemResponse("No post of the id"),
+		},
+	},
+	"SearchHandler": {
+		summary: "Search the posts",
+		parameters: []OpenAPIParameter{
+			{Name: "q", In: "query", Description: "Search query", Required: true, Schema: stringSchema, Example: "post"},
+			queryParam("per_page", "Page size", integerSchema),
+			queryParam("cursor", "next_cursor of the previous page", stringSchema),
+			formatParam,
+		},
+		responses: map[string]OpenAPIResponse{
+			"200": jsonResponse("The posts ranked by score", dataSchema(&OpenAPISchema{Type: "array", Items: schemaRef("PostHit")},
+				map[string]*OpenAPISchema{"next_cursor": {Type: "string", Description: "Blank on the last page"}})),
+			"400": problemResponse("Missing query or invalid param"),
+		},
+	},
+	"GraphQLHandler": {
+		summary: "Execute a GraphQL query, the mutations are only sent by POST with the bearer token",
+		parameters: []OpenAPIParameter{
+			{Name: "query", In: "query", Description: "GraphQL query of a GET", Schema: stringSchema, Example: "{ posts(first: 1) { totalCount } }"},
+			queryParam("operationName", "Operation to execute", stringSchema),
+			queryParam("variables", "JSON object of the variables", stringSchema),
+		},
+		body: &OpenAPIRequestBody{Content: map[string]OpenAPIMediaType{"application/json": {Schema: &OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*OpenAPISchema{
+				"query":         stringSchema,
+				"operationName": stringSchema,
+				"variables":     {Type: "object"},
+			},
+			Required: []string{"query"},
+		}}}},
+		responses: map[string]OpenAPIResponse{
+			"200": jsonResponse("The result of the query", &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{
+				"data":   {Type: "object", Nullable: true},
+				"errors": {Type: "array", Items: &OpenAPISchema{Type: "object"}},
+			}}),
+			"400": jsonResponse("Invalid query or query over the limits", &OpenAPISchema{Type: "object"}),
+			"401": jsonResponse("Mutation without the bearer token", &OpenAPISchema{Type: "object"}),
+			"403": jsonResponse("Mutation on a server without a mutation token", &OpenAPISchema{Type: "object"}),
+		},
+	},
+	"HealthzHandler": {
+		summary:   "Liveness probe",
+		responses: map[string]OpenAPIResponse{"200": jsonResponse("The process is alive", schemaRef("HealthStatus"))},
+	},
+	"ReadyzHandler": {
+		summary: "Readiness probe",
+		responses: map[string]OpenAPIResponse{
+			"200": jsonResponse("The dependencies are ready", schemaRef("HealthStatus")),
+			"503": jsonResponse("A dependency isn't ready", schemaRef("HealthStatus")),
+		},
+	},
+	"OpenAPIHandler": {
+		summary:   "This OpenAPI document",
+		responses: map[string]OpenAPIResponse{"200": jsonResponse("The OpenAPI document", &OpenAPISchema{Type: "object"})},
+	},
+	"SwaggerUIHandler": {
+		summary:   "Swagger UI of the OpenAPI document",
+		responses: map[string]OpenAPIResponse{"200": htmlResponse("HTML page")},
+	},
+	"AssetsHandler": {
+		summary: "Get a webpacker pack, by its fingerprinted path or its logical name in manifest.json",
+		responses: map[string]OpenAPIResponse{
+			"200": {Description: "The pack, or its precompressed variant with a Content-Encoding", Content: map[string]OpenAPIMediaType{"*/*": {Schema: &OpenAPISchema{Type: "string", Format: "binary"}}}},
+			"304": {Description: "Not modified since the ETag of If-None-Match"},
+			"404": {Description: "No such pack"},
+		},
+	},
+	"HomeHandler": {
+		summary:   "About page with the versions of the app",
+		responses: map[string]OpenAPIResponse{"200": htmlResponse("HTML page")},
+	},
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+var nonWordRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// ginParamRegexp matches the params of the gin paths, e.g. :id.
+var ginParamRegexp = regexp.MustCompile(`[:*](\w+)`)
+
+// handlerName is the name of the function of a route handler, e.g. IndexHandler for
+// "app/controllers.IndexHandler" or GraphQLHandler for "app/controllers.GraphQLHandler.func1".
+func handlerName(handler string) string {
+	parts := strings.Split(handler, ".")
+	for len(parts) > 1 && strings.HasPrefix(parts[len(parts)-1], "func") {
+		parts = parts[:len(parts)-1]
+	}
+	return parts[len(parts)-1]
+}
+
+// NewOpenAPIDocument builds the OpenAPI document of the routes, the HEAD routes are left out.
+func NewOpenAPIDocument(routes gin.RoutesInfo) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI:    "3.0.3",
+		Info:       OpenAPIInfo{Title: "go_app", Version: m.SchemaVersion},
+		Paths:      map[string]map[string]OpenAPIOperation{},
+		Components: OpenAPIComponents{Schemas: map[string]*OpenAPISchema{}},
+	}
+	for t, name := range openAPIModels {
+		doc.Components.Schemas[name] = structSchema(t)
+	}
+	operationIds := map[string]bool{}
+	for _, route := range routes {
+		if route.Method == http.MethodHead {
+			continue
+		}
+		path := ginParamRegexp.ReplaceAllString(route.Path, "{$1}")
+		name := handlerName(route.Handler)
+		op := OpenAPIOperation{
+			OperationID: strings.ToLower(route.Method) + strings.TrimSuffix(name, "Handler"),
+			Responses:   map[string]OpenAPIResponse{"200": {Description: "OK"}},
+		}
+		rd, documented := openAPIRoutes[name]
+		if !documented {
+			op.OperationID = strings.ToLower(route.Method) + pathSuffix(path)
+		}
+		// a handler routed twice, e.g. on / and /posts, has an operationId per path
+		if operationIds[op.OperationID] {
+			op.OperationID += pathSuffix(path)
+		}
+		operationIds[op.OperationID] = true
+		if documented {
+			op.Summary, op.Description, op.Parameters, op.Responses = rd.summary, rd.description, rd.parameters, rd.responses
+			if route.Method != http.MethodGet {
+				op.RequestBody = rd.body
+			}
+		}
+		// every gin param of the path is a parameter of the operation
+		for _, match := range ginParamRegexp.FindAllStringSubmatch(route.Path, -1) {
+			if !hasParam(op.Parameters, match[1], "path") {
+				op.Parameters = append(op.Parameters, OpenAPIParameter{Name: match[1], In: "path", Required: true, Schema: stringSchema})
+			}
+		}
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]OpenAPIOperation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+// pathSuffix is a path in camel case for the operationIds, e.g. PostsSearch for /posts/search.
+func pathSuffix(path string) string {
+	suffix := ""
+	for _, word := range nonWordRegexp.Split(path, -1) {
+		if word != "" {
+			suffix += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	if suffix == "" {
+		return "Root"
+	}
+	return suffix
+}
+
+func hasParam(params []OpenAPIParameter, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+// structSchema is the object schema of the JSON of a struct, the fields always marshaled are required.
+func structSchema(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}, AdditionalProperties: false}
+	addFields(s, t)
+	sort.Strings(s.Required)
+	return s
+}
+
+func addFields(s *OpenAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		// the fields of an embedded struct are inlined, e.g. the Post of a PostHit
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			addFields(s, f.Type)
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		prop := typeSchema(f.Type)
+		if prop.Ref == "" {
+			applyValidTag(prop, f.Tag.Get("valid"))
+		}
+		s.Properties[name] = prop
+		// encoding/json doesn't omit the empty structs, the Nulls included, but omitzero omits the zero times
+		omitted := strings.Contains(opts, "omitzero") || strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Struct
+		if !omitted {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func isNull(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && strings.HasPrefix(t.Name(), "Null[") && t.Implements(valuerType)
+}
+
+// typeSchema maps a Go type to a schema, the component types are referenced and a Null is nullable.
+func typeSchema(t reflect.Type) *OpenAPISchema {
+	if name, ok := openAPIModels[t]; ok {
+		return schemaRef(name)
+	}
+	if isNull(t) {
+		v, _ := t.FieldByName("V")
+		s := typeSchema(v.Type)
+		s.Nullable = true
+		return s
+	}
+	switch t {
+	case timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &OpenAPISchema{}
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: typeSchema(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: typeSchema(t.Elem()), Nullable: true}
+	case reflect.Ptr:
+		s := typeSchema(t.Elem())
+		s.Nullable = true
+		return s
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	// an interface{} is any value
+	return &OpenAPISchema{}
+}
+
+// validArgRegexp matches a validator of a valid tag with its args, e.g. length(10|50).
+var validArgRegexp = regexp.MustCompile(`^(\w+)\((.*)\)$`)
+
+// applyValidTag turns the govalidator validators of a valid tag into the constraints of a schema:
+// length into minLength and maxLength, range into minimum and maximum, matches into a pattern,
+// in into an enum and email into a format. A required string has a minLength of 1.
+func applyValidTag(s *OpenAPISchema, tag string) {
+	required := false
+	for _, v := range splitValidTag(tag) {
+		args := []string{}
+		if match := validArgRegexp.FindStringSubmatch(v); match != nil {
+			v, args = match[1], strings.Split(match[2], "|")
+		}
+		switch v {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "length", "stringlength", "runelength":
+			if len(args) == 2 {
+				s.MinLength, s.MaxLength = parseLimit(args[0]), parseLimit(args[1])
+			}
+		case "range":
+			if len(args) == 2 {
+				if min, err := strconv.ParseFloat(args[0], 64); err == nil {
+					s.Minimum = &min
+				}
+				if max, err := strconv.ParseFloat(args[1], 64); err == nil {
+					s.Maximum = &max
+				}
+			}
+		case "in":
+			s.Enum = args
+		case "matches":
+			// the Ruby anchors of the Rails formats
+			s.Pattern = strings.NewReplacer(`\A`, "^", `\z`, "$", `\Z`, "$").Replace(strings.Join(args, "|"))
+		}
+	}
+	if required && s.Type == "string" && s.MinLength == nil {
+		one := int64(1)
+		s.MinLength = &one
+	}
+}
+
+func parseLimit(s string) *int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// splitValidTag splits a valid tag on the commas outside the parentheses of the args.
+func splitValidTag(tag string) []string {
+	validators := []string{}
+	depth, start := 0, 0
+	for i, r := range tag {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				validators = append(validators, tag[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if tag[start:] != "" && tag[start:] != "-" {
+		validators = append(validators, tag[start:])
+	}
+	return validators
+}
+
+// OpenAPIHandler serves the OpenAPI document of the routes of r, it's built on the first request
+// so the routes registered after it are included.
+func OpenAPIHandler(r *gin.Engine) gin.HandlerFunc {
+	var once sync.Once
+	var doc *OpenAPIDocument
+	return func(c *gin.Context) {
+		once.Do(func() { doc = NewOpenAPIDocument(r.Routes()) })
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// SwaggerUIHandler renders the Swagger UI of /openapi.json.
+func SwaggerUIHandler(c *gin.Context) {
+	c.HTML(http.StatusOK, "swagger.tmpl", gin.H{"SpecURL": "/openapi.json"})
+}
+
+// ValidateResponse checks a response of a route against the document, it returns the drifts,
+// e.g. "GET /posts/{id} 200: $.data.title: expected string, got number". The path is the gin
+// path of the route or its path in the document. The constraints of the valid tags are only checked on the requests,
+// the records saved before them may not follow them.
+func (doc *OpenAPIDocument) ValidateResponse(method, path string, status int, contentType string, body []byte) []string {
+	path = ginParamRegexp.ReplaceAllString(path, "{$1}")
+	prefix := fmt.Sprintf("%s %s %d", method, path, status)
+	op, ok := doc.Paths[path][strings.ToLower(method)]
+	if !ok {
+		return []string{prefix + ": undocumented route"}
+	}
+	res, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return []string{prefix + ": undocumented status"}
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mt, ok := res.Content[strings.TrimSpace(mediaType)]
+	if !ok {
+		if len(res.Content) == 0 {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: undocumented content type %q", prefix, mediaType)}
+	}
+	if !strings.Contains(mediaType, "json") {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []string{fmt.Sprintf("%s: invalid JSON: %v", prefix, err)}
+	}
+	drifts := []string{}
+	doc.validate(mt.Schema, v, "$", func(at, msg string) {
+		drifts = append(drifts, fmt.Sprintf("%s: %s: %s", prefix, at, msg))
+	})
+	return drifts
+}
+
+// validate checks the type, the properties and the items of a decoded JSON value.
+func (doc *OpenAPIDocument) validate(s *OpenAPISchema, v interface{}, at string, drift func(at, msg string)) {
+	if s.Ref != "" {
+		ref := doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		if ref == nil {
+			drift(at, "unknown schema "+s.Ref)
+			return
+		}
+		s = ref
+	}
+	if v == nil {
+		if !s.Nullable && s.Type != "" {
+			drift(at, "unexpected null")
+		}
+		return
+	}
+	switch s.Type {
+	case "":
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			drift(at, fmt.Sprintf("expected object, got %s", jsonType(v)))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				drift(at, fmt.Sprintf("missing property %q", name))
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				doc.validate(prop, obj[k], at+"."+k, drift)
+			} else if extra, ok := s.AdditionalProperties.(*OpenAPISchema); ok {
+				doc.validate(extra, obj[k], at+"."+k, drift)
+			} else if s.AdditionalProperties == false {
+				drift(at, fmt.Sprintf("undocumented property %q", k))
+			}
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			drift(at, fmt.Sprintf("expected array, got %s", jsonType(v)))
+			return
+		}
+		for i, item := range items {
+			doc.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i), drift)
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != float64(int64(n)) {
+			drift(at, fmt.Sprintf("expected integer, got %s", jsonType(v)))
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			drift(at, fmt.Sprintf("expected string, got %s", jsonType(v)))
+		} else if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				drift(at, fmt.Sprintf("invalid date-time %q", str))
+			}
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			drift(at, fmt.Sprintf("expected number, got %s", jsonType(v)))
+		}
+	default:
+		if jsonType(v) != s.Type {
+			drift(at, fmt.Sprintf("expected %s, got %s", s.Type, jsonType(v)))
+		}
+	}
+}
+
+// jsonType is the schema type of a decoded JSON value.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	}
+	return "null"
+}
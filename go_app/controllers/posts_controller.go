@@ -1,29 +1,140 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
-	m "../src/models"
 	"github.com/gin-gonic/gin"
+	m "go_app/src/models"
 )
 
+// IndexHandler lists a page of the posts, filtered by the filter params, ordered by the sort param and
+// restricted to the fields param, e.g. /posts?filter[user_id]=3&filter[created_at][gte]=2024-01-01&sort=-created_at,title&fields=id,title
+// The page has page[size] posts, 10 by default. Ordered by id or -id, the following page is got
+// with page[after] set to the next_after of the response, it's 0 on the last page. The pages are
+// keyed on the ids only, so with another sort only the first page is listed.
+// With an Accept of JSONAPIMediaType the posts are rendered as a JSON:API document instead.
+// The contents are rendered from Markdown to HTML with the param format=html, in every handler.
+// A browser, i.e. an Accept of text/html, gets the server-rendered posts page instead.
 func IndexHandler(c *gin.Context) {
-	posts, err := m.AllPosts()
+	c.Header("Vary", "Accept")
+	if wantsHTMLPage(c) && !wantsJSONAPI(c) {
+		htmlIndex(c)
+		return
+	}
+	html, err := contentFormat(c.Query("format"))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	if wantsJSONAPI(c) {
+		jsonAPIIndex(c, html)
+		return
+	}
+	query := c.Request.URL.Query()
+	where, args, err := m.PostRepository.WhereClause(parseFilters(query), nil)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	sorts, err := parseSort(query.Get("sort"), m.PostRepository.Columns())
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	var fields []string
+	if query.Get("fields") != "" {
+		if fields, err = parseFields(query.Get("fields"), m.PostRepository.Columns()); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+	}
+	size, after, err := parsePage(query)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	// the pages are keyed on the ids, a page following another is only got in the order of the ids
+	keyed := len(sorts) == 0 || len(sorts) == 1 && sorts[0].Column == "id"
+	if after != 0 && !keyed {
+		c.Error(fmt.Errorf("page[after] needs the posts sorted by id or -id, the posts sorted by other columns aren't paginated")).SetType(gin.ErrorTypeBind)
+		return
+	}
+	order := sorts
+	if !slices.ContainsFunc(sorts, func(s m.Sort) bool { return s.Column == "id" }) {
+		order = append(order, m.Sort{Column: "id"})
+	}
+	// a post more tells whether there's a next page
+	page := &m.PostPage{WhereString: where, WhereParams: args, Order: order, PerPage: size + 1, Context: c.Request.Context()}
+	posts, err := page.After(after)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var nextAfter int64
+	if len(posts) > size {
+		posts = posts[:size]
+		if keyed {
+			nextAfter = posts[size-1].Id
+		}
+	}
+	if html {
+		if err := renderContents(posts); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if len(fields) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"data":       posts,
+			"next_after": nextAfter,
+		})
+		return
+	}
+	data, err := sparseFieldset(posts, fields)
 	if err != nil {
-		c.String(http.StatusNotFound, "Posts not found or some error occurred!")
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"data": posts,
+		"data":       data,
+		"next_after": nextAfter,
 	})
 }
 
 func ShowHandler(c *gin.Context) {
 	id, err := ToInt(c.Param("id"))
-	post, err := m.FindPost(id)
 	if err != nil {
-		c.String(http.StatusNotFound, "Post not found or some error occurred!")
+		c.Error(fmt.Errorf("invalid post id %q", c.Param("id"))).SetType(gin.ErrorTypeBind)
+		return
+	}
+	html, err := contentFormat(c.Query("format"))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	post, err := m.FindPostContext(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Vary", "Accept")
+	if wantsHTMLPage(c) && !wantsJSONAPI(c) {
+		htmlShow(c, post)
+		return
+	}
+	if html {
+		if err := renderContent(post); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if wantsJSONAPI(c) {
+		jsonAPIShow(c, post)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -31,6 +142,47 @@ func ShowHandler(c *gin.Context) {
 	})
 }
 
+// SearchHandler searches the posts by the query param q, the next page is got with the
+// next_cursor of the response as the cursor param.
+func SearchHandler(c *gin.Context) {
+	q := c.Query("q")
+	if strings.TrimSpace(q) == "" {
+		c.Error(errors.New("missing search query q")).SetType(gin.ErrorTypeBind)
+		return
+	}
+	html, err := contentFormat(c.Query("format"))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	page := &m.SearchPage{Cursor: c.Query("cursor"), Context: c.Request.Context()}
+	if perPage := c.Query("per_page"); perPage != "" {
+		n, err := ToInt(perPage)
+		if err != nil || n <= 0 {
+			c.Error(fmt.Errorf("invalid per_page %q", perPage)).SetType(gin.ErrorTypeBind)
+			return
+		}
+		page.PerPage = int(n)
+	}
+	hits, err := m.SearchPosts(q, page)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if html {
+		for i := range hits {
+			if err := renderContent(&hits[i].Post); err != nil {
+				c.Error(err)
+				return
+			}
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        hits,
+		"next_cursor": page.NextCursor,
+	})
+}
+
 func ToInt(s string) (int64, error) {
 	res, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
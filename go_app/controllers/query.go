@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	m "go_app/src/models"
+)
+
+// filterParamRegexp matches the filter params, e.g. filter[user_id] and filter[created_at][gte].
+var filterParamRegexp = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+// parseFilters reads the filter params of a query, the op is "eq" if it's not given.
+func parseFilters(values url.Values) []m.Filter {
+	keys := []string{}
+	for k := range values {
+		keys = append(keys, k)
+	}
+	// sorted so the same params build the same SQL
+	sort.Strings(keys)
+	filters := []m.Filter{}
+	for _, k := range keys {
+		match := filterParamRegexp.FindStringSubmatch(k)
+		if match == nil {
+			continue
+		}
+		op := match[2]
+		if op == "" {
+			op = "eq"
+		}
+		for _, v := range values[k] {
+			filters = append(filters, m.Filter{Column: match[1], Op: op, Value: v})
+		}
+	}
+	return filters
+}
+
+// parseSort reads a sort param, e.g. "-created_at,title" is created_at DESC, title ASC,
+// against the columns of a model.
+func parseSort(s string, columns []string) ([]m.Sort, error) {
+	known := map[string]bool{}
+	for _, c := range columns {
+		known[c] = true
+	}
+	sorts := []m.Sort{}
+	for _, col := range strings.Split(s, ",") {
+		col = strings.TrimSpace(col)
+		if col == "" {
+			continue
+		}
+		desc := strings.HasPrefix(col, "-")
+		col = strings.TrimPrefix(col, "-")
+		if !known[col] {
+			return nil, fmt.Errorf("unknown sort field %q", col)
+		}
+		sorts = append(sorts, m.Sort{Column: col, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// parsePage reads the params page[size], 10 by default and at most 100, and page[after]
+// of a page of the posts keyed on the ids.
+func parsePage(values url.Values) (size int, after int64, err error) {
+	size = 10
+	if s := values.Get("page[size]"); s != "" {
+		n, err := ToInt(s)
+		if err != nil || n <= 0 || n > 100 {
+			return 0, 0, fmt.Errorf("invalid page[size] %q", s)
+		}
+		size = int(n)
+	}
+	if a := values.Get("page[after]"); a != "" {
+		if after, err = ToInt(a); err != nil || after < 0 {
+			return 0, 0, fmt.Errorf("invalid page[after] %q", a)
+		}
+	}
+	return size, after, nil
+}
+
+// parseFields reads a sparse fieldset param, e.g. "id,title", against the columns of a model.
+func parseFields(s string, columns []string) ([]string, error) {
+	known := map[string]bool{}
+	for _, c := range columns {
+		known[c] = true
+	}
+	fields := []string{}
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !known[f] {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// sparseFieldset keeps only the fields in the JSON objects of the records.
+func sparseFieldset(records interface{}, fields []string) ([]map[string]json.RawMessage, error) {
+	b, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+	objects := []map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &objects); err != nil {
+		return nil, err
+	}
+	for i, o := range objects {
+		sparse := make(map[string]json.RawMessage, len(fields))
+		for _, f := range fields {
+			if v, ok := o[f]; ok {
+				sparse[f] = v
+			}
+		}
+		objects[i] = sparse
+	}
+	return objects, nil
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of the request spans.
+const TracerName = "go_app/controllers"
+
+// Tracing is a middleware to record a server span for every request, continuing the trace
+// of the traceparent header sent by the client. The span is put in c.Request.Context(),
+// so the model queries run with that context are recorded as its children.
+func Tracing(service string) gin.HandlerFunc {
+	tracer := otel.GetTracerProvider().Tracer(TracerName)
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := route
+		if name == "" {
+			name = c.Request.Method + " unmatched route"
+		} else {
+			name = c.Request.Method + " " + route
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.server_name", service),
+				attribute.String("http.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("http.target", c.Request.URL.RequestURI()),
+				attribute.String("http.user_agent", c.Request.UserAgent()),
+				attribute.String("net.peer.ip", c.ClientIP()),
+			),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.status_code", status))
+		if id := c.GetString(requestIDKey); id != "" {
+			span.SetAttributes(attribute.String("http.request_id", id))
+		}
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("%d %s", status, http.StatusText(status)))
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	m "go_app/src/models"
+)
+
+// Schema drift check modes of the flag -schema-drift.
+const (
+	driftOff    = "off"
+	driftWarn   = "warn"
+	driftStrict = "strict"
+)
+
+// checkSchemaDrift compares the model structs to the live tables at startup, the drifts are
+// logged in the warn mode and fail the startup in the strict mode.
+func checkSchemaDrift(mode string) error {
+	switch mode {
+	case driftOff:
+		return nil
+	case driftWarn, driftStrict:
+	default:
+		return fmt.Errorf("Invalid schema drift mode: %s", mode)
+	}
+	drifts, err := m.SchemaDrift()
+	if err != nil {
+		return fmt.Errorf("Check schema drift error: %w", err)
+	}
+	for _, d := range drifts {
+		log.Printf("Schema drift: %s\n", d)
+	}
+	if len(drifts) > 0 && mode == driftStrict {
+		return fmt.Errorf("%d schema drifts found, regenerate the models or migrate the database", len(drifts))
+	}
+	return nil
+}
+
+// driftCommand is the subcommand "drift", it reports the schema drifts to w
+// and returns the exit code: 0 without drifts, 1 with drifts and 2 on a failure.
+func driftCommand(w io.Writer) int {
+	drifts, err := m.SchemaDrift()
+	if err != nil {
+		fmt.Fprintf(w, "Check schema drift error: %v\n", err)
+		return 2
+	}
+	for _, d := range drifts {
+		fmt.Fprintln(w, d)
+	}
+	if len(drifts) > 0 {
+		fmt.Fprintf(w, "%d schema drifts found\n", len(drifts))
+		return 1
+	}
+	fmt.Fprintln(w, "No schema drift")
+	return 0
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+)
+
+func TestDriftCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		// changes the database opened by modeltest.Open
+		change func(t *testing.T)
+		code   int
+		out    string
+	}{
+		{"no drift", nil, 0, "No schema drift"},
+		{"drift", func(t *testing.T) {
+			if _, err := m.DB.Exec(`ALTER TABLE posts ADD COLUMN "slug" varchar(255)`); err != nil {
+				t.Fatal(err)
+			}
+		}, 1, "(posts.slug) extra_column"},
+		{"failure", func(t *testing.T) {
+			if err := m.DB.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}, 2, "Check schema drift error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modeltest.Open(t)
+			if tt.change != nil {
+				tt.change(t)
+			}
+			var out bytes.Buffer
+			if code := driftCommand(&out); code != tt.code {
+				t.Errorf("drift exit code = %d, want %d:\n%s", code, tt.code, out.String())
+			}
+			if !strings.Contains(out.String(), tt.out) {
+				t.Errorf("drift output = %q, want %q in it", out.String(), tt.out)
+			}
+		})
+	}
+}
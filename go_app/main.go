@@ -1,30 +1,262 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
-	c "./controllers"
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	c "go_app/controllers"
+	"go_app/rpc"
+	m "go_app/src/models"
+	"google.golang.org/grpc"
 )
 
+// defaultDSN is the MySQL database of the Rails development environment, see config/database.yml.
+const defaultDSN = "root:@tcp(localhost:3306)/simple_example_with_admin_development?charset=utf8&parseTime=True&loc=Local"
+
 func main() {
 	// The app will run on port 4000 by default, you can custom it with the flag -port
 	servePort := flag.String("port", "4000", "Http Server Port")
-	flag.Parse()
-
-	// Here we are instantiating the router
-	r := gin.Default()
-	r.Use(cors.Default())
-	// Switch to "release" mode in production
-	// gin.SetMode(gin.ReleaseMode)
-	r.LoadHTMLGlob("views/*")
-	// Create a static assets router
-	// r.Static("/assets", "./public/assets")
-	r.StaticFile("/favicon.ico", "./public/favicon.ico")
-	// Then we bind some route to some handler(controller action)
-	r.GET("/", c.IndexHandler)
-	r.GET("/posts/:id", c.ShowHandler)
+	// The canonical and Open Graph URLs of the pages are on the base URL, http://localhost:<port> by default
+	baseURL := flag.String("base-url", "", "Scheme and host of the absolute URLs of the pages, e.g. https://example.com")
+	// The database is MySQL by default, or postgres with -db-driver pgx, or sqlite3 for the local tests,
+	// the reads go to the comma separated read replicas if they're given
+	dbDriver := flag.String("db-driver", "mysql", "Database driver: mysql, pgx or sqlite3")
+	dbDSN := flag.String("db-dsn", "", "Database DSN, the Rails development database on MySQL by default")
+	dbReplicaDSNs := flag.String("db-replica-dsns", "", "Comma separated DSNs of the read replicas")
+	// The validation error messages are read from the Rails locale files
+	localesDir := flag.String("locales", "../config/locales", "Rails locales directory")
+	// Queries slower than the threshold are logged as warnings, 0 disables it
+	slowQuery := flag.Duration("slow-query", 200*time.Millisecond, "Slow query threshold")
+	// The spans of the requests and the queries are exported to stdout with -trace stdout
+	traceExporter := flag.String("trace", "", "Trace exporter: stdout, memory or none")
+	// The readiness probe fails if the database doesn't answer the ping in time, or with -check-schema
+	// if it isn't migrated to the schema version of the models
+	readyTimeout := flag.Duration("ready-timeout", 2*time.Second, "Readiness probe database timeout")
+	checkSchema := flag.Bool("check-schema", false, "Check the schema version in the readiness probe")
+	// The server timeouts, 0 means no timeout
+	readTimeout := flag.Duration("read-timeout", 10*time.Second, "Http Server read timeout")
+	readHeaderTimeout := flag.Duration("read-header-timeout", 5*time.Second, "Http Server read header timeout")
+	writeTimeout := flag.Duration("write-timeout", 30*time.Second, "Http Server write timeout")
+	idleTimeout := flag.Duration("idle-timeout", 120*time.Second, "Http Server idle timeout")
+	// On SIGTERM or SIGINT the in-flight requests are drained for up to the shutdown timeout
+	shutdownTimeout := flag.Duration("shutdown-timeout", 15*time.Second, "Graceful shutdown timeout")
+	// The server uses TLS when both the certificate and the key files are given
+	tlsCert := flag.String("tls-cert", "", "TLS certificate file")
+	tlsKey := flag.String("tls-key", "", "TLS key file")
+	// The model structs are compared to the live tables at startup, "strict" fails on any drift
+	schemaDrift := flag.String("schema-drift", driftWarn, "Schema drift check: off, warn or strict")
+	// The posts are searched with the FULLTEXT index on MySQL, the tsvector on PostgreSQL and an
+	// in-process index on SQLite, auto picks the one of the driver
+	searchBackend := flag.String("search", "auto", "Search backend: auto, fulltext, tsvector or memory")
+	// The GraphQL queries over the limits are rejected before their execution
+	graphQLMaxDepth := flag.Int("graphql-max-depth", 10, "GraphQL query depth limit")
+	graphQLMaxComplexity := flag.Int("graphql-max-complexity", 1000, "GraphQL query complexity limit")
+	// The GraphQL mutations are disabled unless they're authorized by a bearer token
+	graphQLMutationToken := flag.String("graphql-mutation-token", "", "Bearer token of the GraphQL mutations, none disables them")
+	// The gRPC API is served on its own port, or multiplexed with HTTP/2 on the -port if they're the same,
+	// a blank port disables it
+	grpcPort := flag.String("grpc-port", "4001", "gRPC Server Port")
+	// The gRPC calls are authorized by a bearer token, the gRPC API isn't served without it
+	grpcToken := flag.String("grpc-token", "", "Bearer token of the gRPC calls, none disables the gRPC API")
+	// The webpacker packs are served on /packs from the directory, or from the binary built by
+	// "make embed" if it's blank
+	assetsDir := flag.String("assets", defaultAssetsDir, "Webpacker packs directory, blank for the embedded packs")
+	// Every flag can be set in a YAML config file or by an environment variable, e.g. GO_APP_PORT
+	flag.String("config", "", "YAML config file")
+	if err := loadConfig(flag.CommandLine, os.Args[1:]); err != nil {
+		log.Fatalf("Load config error: %v\n", err)
+	}
+
+	// set flags to output more detailed log
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	dsn := *dbDSN
+	if dsn == "" && *dbDriver == "mysql" {
+		dsn = defaultDSN
+	}
+	if dsn == "" {
+		log.Fatalf("Invalid DSN, -db-dsn is required with the driver %s\n", *dbDriver)
+	}
+	if err := m.Open(*dbDriver, dsn); err != nil {
+		log.Fatalf("Open database error: %v\n", err)
+	}
+	if *dbReplicaDSNs != "" {
+		if err := m.OpenReplicas(strings.Split(*dbReplicaDSNs, ",")...); err != nil {
+			log.Fatalf("Open replicas error: %v\n", err)
+		}
+	}
+
+	// "go_app drift" only reports the schema drifts
+	if flag.Arg(0) == "drift" {
+		os.Exit(driftCommand(os.Stdout))
+	}
+	// the gin logs of "go_app openapi" go to stderr so it only outputs the document
+	if flag.Arg(0) == "openapi" {
+		gin.DefaultWriter = os.Stderr
+	}
+	if err := checkSchemaDrift(*schemaDrift); err != nil {
+		log.Fatal(err)
+	}
+	m.AddQueryObserver(m.NewSlogObserver(slog.Default(), *slowQuery))
+
+	shutdownTracing, err := setupTracing(*traceExporter)
+	if err != nil {
+		log.Fatalf("Setup tracing error: %v\n", err)
+	}
+	m.AddQueryObserver(m.NewTracingObserver(nil))
+
+	if err := m.LoadLocales(*localesDir); err != nil {
+		log.Printf("Load locales error: %v\n", err)
+	}
+	// the in-process index holds all the posts, it isn't for the databases of production
+	searchDrivers := map[string]string{"fulltext": "mysql", "tsvector": "pgx", "memory": "sqlite3"}
+	if driver, ok := searchDrivers[*searchBackend]; ok && driver != *dbDriver {
+		log.Fatalf("Invalid search backend %s with the driver %s, it's only for %s\n", *searchBackend, *dbDriver, driver)
+	}
+	switch *searchBackend {
+	case "auto":
+	case "fulltext":
+		m.SetSearchBackend(m.FulltextSearch{})
+	case "tsvector":
+		m.SetSearchBackend(m.TSVectorSearch{})
+	case "memory":
+		m.SetSearchBackend(m.NewMemorySearch())
+	default:
+		log.Fatalf("Unknown search backend %q\n", *searchBackend)
+	}
+
+	if *baseURL == "" {
+		*baseURL = "http://localhost:" + *servePort
+	}
+	if u, err := url.Parse(*baseURL); err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+		log.Fatalf("Invalid base URL %q, it's a scheme and a host, e.g. https://example.com\n", *baseURL)
+	}
+	c.BaseURL = strings.TrimSuffix(*baseURL, "/")
+
+	packs := embeddedPacks
+	if *assetsDir != "" {
+		packs = os.DirFS(*assetsDir)
+	}
+	r, err := newRouter(routerOptions{
+		readyTimeout:         *readyTimeout,
+		checkSchema:          *checkSchema,
+		packs:                packs,
+		graphQLMaxDepth:      *graphQLMaxDepth,
+		graphQLMaxComplexity: *graphQLMaxComplexity,
+		graphQLMutationToken: *graphQLMutationToken,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// "go_app openapi" prints the OpenAPI document, "go_app openapi check" checks the responses against it
+	if flag.Arg(0) == "openapi" {
+		os.Exit(openAPICommand(os.Stdout, r, flag.Arg(1)))
+	}
 	// Let's start the server
-	r.Run(":" + *servePort)
+	var handler http.Handler = r
+	if *grpcToken == "" && *grpcPort != "" {
+		log.Printf("The gRPC API is disabled without a -grpc-token\n")
+		*grpcPort = ""
+	}
+	grpcSrv := rpc.NewServer(grpc.UnaryInterceptor(rpc.AuthInterceptor(*grpcToken)))
+	multiplexed := *grpcPort == *servePort
+	if multiplexed {
+		handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if rpc.IsGRPC(req) {
+				grpcSrv.ServeHTTP(w, req)
+				return
+			}
+			r.ServeHTTP(w, req)
+		})
+	}
+	srv := &http.Server{
+		Addr:              ":" + *servePort,
+		Handler:           handler,
+		ReadTimeout:       *readTimeout,
+		ReadHeaderTimeout: *readHeaderTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+	}
+	if multiplexed {
+		// the gRPC clients talk HTTP/2 without TLS
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	errc := make(chan error, 2)
+	if *grpcPort != "" && !multiplexed {
+		lis, err := net.Listen("tcp", ":"+*grpcPort)
+		if err != nil {
+			log.Fatalf("gRPC listen error: %v\n", err)
+		}
+		go func() {
+			log.Printf("Listening and serving gRPC on %s\n", lis.Addr())
+			errc <- grpcSrv.Serve(lis)
+		}()
+	}
+	go func() {
+		if *tlsCert != "" && *tlsKey != "" {
+			log.Printf("Listening and serving HTTPS on %s\n", srv.Addr)
+			errc <- srv.ListenAndServeTLS(*tlsCert, *tlsKey)
+		} else {
+			log.Printf("Listening and serving HTTP on %s\n", srv.Addr)
+			errc <- srv.ListenAndServe()
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	exitCode := 0
+	select {
+	case err := <-errc:
+		log.Printf("Server error: %v\n", err)
+		// e.g. the port is already bound, it isn't a clean exit for the orchestrator
+		exitCode = 1
+	case <-ctx.Done():
+		stop()
+		log.Println("Shutting down the server")
+	}
+
+	// Drain the connections, then close the database once no request uses it
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown error: %v\n", err)
+	}
+	stopGRPC(shutdownCtx, grpcSrv)
+	if err := m.Close(); err != nil {
+		log.Printf("Close database error: %v\n", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Shutdown tracing error: %v\n", err)
+	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// stopGRPC stops the gRPC server gracefully, the calls still running when ctx is done are canceled.
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		srv.Stop()
+	}
 }
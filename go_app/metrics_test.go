@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+)
+
+// scrapeMetrics gets /metrics and returns the values of the samples by their name and labels,
+// e.g. `go_sql_max_open_connections{db_name="primary"}`.
+func scrapeMetrics(t *testing.T, r *gin.Engine) map[string]float64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d, want 200", w.Code)
+	}
+	samples := map[string]float64{}
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		if i < 0 {
+			continue
+		}
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("Parse sample %q error: %v", line, err)
+		}
+		samples[line[:i]] = v
+	}
+	return samples
+}
+
+// TestMetrics checks the models metrics scraped on /metrics: the pool stats of the database opened
+// after the router is built, and the queries counted once whatever the count of the routers.
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter(routerOptions{graphQLMaxDepth: 10, graphQLMaxComplexity: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newRouter(routerOptions{graphQLMaxDepth: 10, graphQLMaxComplexity: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	modeltest.Open(t)
+	m.DB.SetMaxOpenConns(3)
+
+	counted := `models_queries_total{model="Post",operation="select",status="ok"}`
+	before := scrapeMetrics(t, r)
+	if got := before[`go_sql_max_open_connections{db_name="primary"}`]; got != 3 {
+		t.Errorf("go_sql_max_open_connections = %v, want 3 of the database opened last", got)
+	}
+	if _, err := m.PostCountWhere("1 = 1"); err != nil {
+		t.Fatal(err)
+	}
+	after := scrapeMetrics(t, r)
+	if got := after[counted] - before[counted]; got != 1 {
+		t.Errorf("%s went up by %v for a query, want 1", counted, got)
+	}
+	if _, ok := after[`models_stmt_cache_size`]; !ok {
+		t.Error("no models_stmt_cache_size")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	c "go_app/controllers"
+	m "go_app/src/models"
+)
+
+// openAPICommand is the subcommand "openapi", it writes the OpenAPI document of the routes of r
+// to w, or with the arg "check" it requests the GET routes returning JSON and reports the responses
+// drifting from the document. It returns the exit code: 0 without drifts, 1 with drifts and 2 on
+// a failure. The {id} params are the id of the first post and the query params their examples.
+func openAPICommand(w io.Writer, r *gin.Engine, arg string) int {
+	doc := c.NewOpenAPIDocument(r.Routes())
+	switch arg {
+	case "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			fmt.Fprintf(w, "Encode OpenAPI document error: %v\n", err)
+			return 2
+		}
+		return 0
+	case "check":
+	default:
+		fmt.Fprintf(w, "Unknown openapi command %q, it's check or none\n", arg)
+		return 2
+	}
+	id := ""
+	if post, err := m.FirstPost(); err == nil {
+		id = strconv.FormatInt(post.Id, 10)
+	}
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	drifts, checked := []string{}, 0
+	for _, path := range paths {
+		op, ok := doc.Paths[path]["get"]
+		if !ok {
+			continue
+		}
+		target := path
+		if strings.Contains(target, "{id}") {
+			if id == "" {
+				fmt.Fprintf(w, "Skip GET %s: no post\n", path)
+				continue
+			}
+			target = strings.ReplaceAll(target, "{id}", id)
+		}
+		query := url.Values{}
+		for _, p := range op.Parameters {
+			if p.In == "query" && p.Example != nil {
+				query.Set(p.Name, fmt.Sprint(p.Example))
+			}
+		}
+		if len(query) > 0 {
+			target += "?" + query.Encode()
+		}
+		for _, accept := range []string{"application/json", c.JSONAPIMediaType, "text/html"} {
+			if _, ok := op.Responses["200"].Content[accept]; !ok {
+				continue
+			}
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.Header.Set("Accept", accept)
+			res := httptest.NewRecorder()
+			r.ServeHTTP(res, req)
+			drifts = append(drifts, doc.ValidateResponse(http.MethodGet, path, res.Code, res.Header().Get("Content-Type"), res.Body.Bytes())...)
+			checked++
+		}
+	}
+	for _, d := range drifts {
+		fmt.Fprintln(w, d)
+	}
+	if len(drifts) > 0 {
+		fmt.Fprintf(w, "%d OpenAPI drifts found in %d responses\n", len(drifts), checked)
+		return 1
+	}
+	fmt.Fprintf(w, "No OpenAPI drift in %d responses\n", checked)
+	return 0
+}
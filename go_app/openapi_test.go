@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go_app/src/models/modeltest"
+)
+
+// TestOpenAPIResponses checks the responses of the documented GET routes against the OpenAPI
+// document, on the posts of a SQLite database.
+func TestOpenAPIResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "openapi@example.com")
+	modeltest.CreatePost(t, userId, "A documented post", "Some post content here, long enough")
+
+	r, err := newRouter(routerOptions{graphQLMaxDepth: 10, graphQLMaxComplexity: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if code := openAPICommand(&out, r, "check"); code != 0 {
+		t.Errorf("openapi check exit code = %d, want 0:\n%s", code, out.String())
+	}
+	t.Log(out.String())
+}
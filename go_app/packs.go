@@ -0,0 +1,12 @@
+//go:build !embedassets
+
+package main
+
+import "io/fs"
+
+// defaultAssetsDir is the webpacker output of the Rails app, the packs are read from it
+// unless the binary is built with "make embed".
+const defaultAssetsDir = "../public/packs"
+
+// embeddedPacks is nil, no pack is embedded in this binary.
+var embeddedPacks fs.FS
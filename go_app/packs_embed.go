@@ -0,0 +1,18 @@
+//go:build embedassets
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// packsFS embeds the packs copied to public/packs by "make embed", for a single binary deploy.
+//
+//go:embed all:public/packs
+var packsFS embed.FS
+
+// defaultAssetsDir is blank so the embedded packs are served, unless -assets names a directory.
+const defaultAssetsDir = ""
+
+var embeddedPacks, _ = fs.Sub(packsFS, "public/packs")
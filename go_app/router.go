@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	c "go_app/controllers"
+	m "go_app/src/models"
+)
+
+// routerOptions are the flags of the routes.
+type routerOptions struct {
+	readyTimeout time.Duration
+	checkSchema  bool
+	// the webpacker packs served on /packs, none if nil
+	packs                fs.FS
+	graphQLMaxDepth      int
+	graphQLMaxComplexity int
+	graphQLMutationToken string
+}
+
+// newRouter routes the handlers of the app, the views are read from the views directory.
+func newRouter(o routerOptions) (*gin.Engine, error) {
+	// Here we are instantiating the router
+	r := gin.Default()
+	// The probes are routed before the middlewares so they're neither traced nor measured
+	r.GET("/healthz", c.HealthzHandler)
+	r.GET("/readyz", c.ReadyzHandler(o.readyTimeout, o.checkSchema))
+	// Allow the trace context headers sent by the React client
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	corsConfig.AddAllowHeaders("traceparent", "tracestate", "baggage")
+	r.Use(cors.New(corsConfig))
+	// The metrics of the routes, the Go runtime and the models are exposed on /metrics
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	if err := m.RegisterMetrics(reg); err != nil {
+		return nil, fmt.Errorf("Register metrics error: %w", err)
+	}
+	r.Use(c.Tracing(serviceName), c.Metrics(reg), c.RequestID(), c.ErrorHandler())
+	// Switch to "release" mode in production
+	// gin.SetMode(gin.ReleaseMode)
+	// The pages of views are rendered with its layouts and partials
+	htmlRender, err := c.NewHTMLRender("views")
+	if err != nil {
+		return nil, fmt.Errorf("Load views error: %w", err)
+	}
+	r.HTMLRender = htmlRender
+	// The static assets router, the fingerprinted packs are cached by the browsers for a year
+	if o.packs != nil {
+		assets := c.NewAssets(o.packs, "/packs")
+		if err := assets.LoadManifest(); err != nil {
+			log.Printf("Load packs error: %v\n", err)
+		}
+		r.GET("/packs/*filepath", c.AssetsHandler(assets))
+		r.HEAD("/packs/*filepath", c.AssetsHandler(assets))
+	}
+	r.StaticFile("/favicon.ico", "./public/favicon.ico")
+	// Then we bind some route to some handler(controller action)
+	r.GET("/", c.IndexHandler)
+	r.GET("/posts", c.IndexHandler)
+	r.GET("/posts/search", c.SearchHandler)
+	r.GET("/posts/:id", c.ShowHandler)
+	r.GET("/about", c.HomeHandler)
+	graphQL := c.GraphQLHandler(o.graphQLMaxDepth, o.graphQLMaxComplexity, o.graphQLMutationToken)
+	r.GET("/graphql", graphQL)
+	r.POST("/graphql", graphQL)
+	r.GET("/metrics", gin.WrapH(promhttp.HandlerFor(reg, promhttp.HandlerOpts{})))
+	// The OpenAPI document of the routes, browsable with Swagger UI on /docs
+	r.GET("/openapi.json", c.OpenAPIHandler(r))
+	r.GET("/docs", c.SwaggerUIHandler)
+	return r, nil
+}
@@ -0,0 +1,297 @@
+// Package rpc serves the gRPC API of proto/posts.proto on the models, next to the gin server.
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	m "go_app/src/models"
+	pb "go_app/src/postspb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The page_size of the List methods is defaultPageSize if it's unset and at most maxPageSize.
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// postInputFields are the fields of a Post message written by Create and Update, named as their columns.
+var postInputFields = map[string]bool{"title": true, "content": true, "user_id": true}
+
+// NewServer returns a gRPC server of the PostService and the UserService, with the reflection
+// service registered so it can be explored by grpcurl or grpcui.
+func NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	pb.RegisterPostServiceServer(srv, &postServer{})
+	pb.RegisterUserServiceServer(srv, &userServer{})
+	reflection.Register(srv)
+	return srv
+}
+
+// AuthInterceptor authorizes the unary calls by a bearer token in their "authorization" metadata,
+// e.g. grpcurl -H "authorization: Bearer <token>". Every call is denied for a blank token.
+func AuthInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		authorized := false
+		for _, v := range md.Get("authorization") {
+			got, ok := strings.CutPrefix(v, "Bearer ")
+			authorized = authorized || ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+		}
+		if !authorized {
+			return nil, status.Error(codes.Unauthenticated, "the bearer token of the server is required")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// IsGRPC reports whether a request of the HTTP server is a gRPC call, for serving both on the same port.
+func IsGRPC(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// statusError maps the model errors to the gRPC status codes, the validation errors carry
+// a BadRequest detail of the invalid fields and the unexpected errors are hidden.
+func statusError(err error) error {
+	var ve *m.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		st := status.New(codes.InvalidArgument, err.Error())
+		br := &errdetails.BadRequest{}
+		for _, fe := range ve.Errors {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message})
+		}
+		if withDetails, derr := st.WithDetails(br); derr == nil {
+			st = withDetails
+		}
+		return st.Err()
+	case errors.Is(err, m.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, m.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, m.ErrDuplicate):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, m.ErrForeignKey):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	log.Printf("gRPC handler error: %v\n", err)
+	return status.Error(codes.Internal, "Internal Server Error")
+}
+
+// pageSize checks the page_size of a List request.
+func pageSize(size int32) (int, error) {
+	switch {
+	case size == 0:
+		return defaultPageSize, nil
+	case size < 0 || size > maxPageSize:
+		return 0, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", maxPageSize)
+	}
+	return int(size), nil
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func nullTimestamp(t m.Null[time.Time]) *timestamppb.Timestamp {
+	if !t.Valid {
+		return nil
+	}
+	return timestamp(t.V)
+}
+
+func postMessage(p *m.Post) *pb.Post {
+	return &pb.Post{
+		Id:        p.Id,
+		Title:     p.Title.Ptr(),
+		Content:   p.Content.Ptr(),
+		UserId:    p.UserId.Ptr(),
+		CreatedAt: timestamp(p.CreatedAt),
+		UpdatedAt: timestamp(p.UpdatedAt),
+	}
+}
+
+func userMessage(u *m.User) *pb.User {
+	return &pb.User{
+		Id:              u.Id,
+		Email:           u.Email,
+		SignInCount:     u.SignInCount,
+		CurrentSignInAt: nullTimestamp(u.CurrentSignInAt),
+		LastSignInAt:    nullTimestamp(u.LastSignInAt),
+		CreatedAt:       timestamp(u.CreatedAt),
+		UpdatedAt:       timestamp(u.UpdatedAt),
+		Role:            u.Role.Ptr(),
+	}
+}
+
+// postAttributes is the attributes map of the fields of a Post in paths, of its set fields if paths is empty.
+func postAttributes(p *pb.Post, paths []string) (map[string]interface{}, error) {
+	if p == nil {
+		return nil, status.Error(codes.InvalidArgument, "post is required")
+	}
+	fields := map[string]interface{}{}
+	if p.Title != nil {
+		fields["title"] = *p.Title
+	}
+	if p.Content != nil {
+		fields["content"] = *p.Content
+	}
+	if p.UserId != nil {
+		fields["user_id"] = *p.UserId
+	}
+	if len(paths) == 0 {
+		return fields, nil
+	}
+	am := map[string]interface{}{}
+	for _, path := range paths {
+		if !postInputFields[path] {
+			return nil, status.Errorf(codes.InvalidArgument, "the field %q can't be updated", path)
+		}
+		// a field of the mask that isn't set is cleared
+		am[path] = fields[path]
+	}
+	return am, nil
+}
+
+// postServer implements the PostService with the functions of the Post model.
+type postServer struct {
+	pb.UnimplementedPostServiceServer
+}
+
+func (s *postServer) GetPost(ctx context.Context, req *pb.GetPostRequest) (*pb.Post, error) {
+	post, err := m.FindPostContext(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return postMessage(post), nil
+}
+
+// ListPosts lists the posts by id, a PostPage token of the last post is the next_page_token
+// if there's a next page.
+func (s *postServer) ListPosts(ctx context.Context, req *pb.ListPostsRequest) (*pb.ListPostsResponse, error) {
+	size, err := pageSize(req.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+	// a post more tells whether there's a next page
+	page := &m.PostPage{Order: []m.Sort{{Column: "id"}}, PerPage: size + 1, Context: ctx}
+	after, err := page.ParseToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page_token %q", req.GetPageToken())
+	}
+	posts, err := page.After(after)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	next := len(posts) > size
+	if next {
+		posts = posts[:size]
+	}
+	res := &pb.ListPostsResponse{TotalSize: page.TotalItems}
+	for i := range posts {
+		res.Posts = append(res.Posts, postMessage(&posts[i]))
+	}
+	if next {
+		res.NextPageToken = page.Token(posts[size-1].Id)
+	}
+	return res, nil
+}
+
+func (s *postServer) CreatePost(ctx context.Context, req *pb.CreatePostRequest) (*pb.Post, error) {
+	am, err := postAttributes(req.GetPost(), nil)
+	if err != nil {
+		return nil, err
+	}
+	id, err := m.CreatePost(am)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.GetPost(m.Primary(ctx), &pb.GetPostRequest{Id: id})
+}
+
+func (s *postServer) UpdatePost(ctx context.Context, req *pb.UpdatePostRequest) (*pb.Post, error) {
+	am, err := postAttributes(req.GetPost(), req.GetUpdateMask().GetPaths())
+	if err != nil {
+		return nil, err
+	}
+	id := req.GetPost().GetId()
+	if _, err := m.FindPostContext(m.Primary(ctx), id); err != nil {
+		return nil, statusError(err)
+	}
+	if len(am) > 0 {
+		if err := m.UpdatePost(id, am); err != nil {
+			return nil, statusError(err)
+		}
+	}
+	return s.GetPost(m.Primary(ctx), &pb.GetPostRequest{Id: id})
+}
+
+func (s *postServer) DeletePost(ctx context.Context, req *pb.DeletePostRequest) (*emptypb.Empty, error) {
+	if err := m.DestroyPost(req.GetId()); err != nil {
+		return nil, statusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// userServer implements the UserService with the functions of the User model.
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	user, err := m.FindUserContext(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return userMessage(user), nil
+}
+
+// ListUsers lists the users by id, paginated by UserPage tokens as ListPosts.
+func (s *userServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	size, err := pageSize(req.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+	// a user more tells whether there's a next page
+	page := &m.UserPage{Order: []m.Sort{{Column: "id"}}, PerPage: size + 1, Context: ctx}
+	after, err := page.ParseToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page_token %q", req.GetPageToken())
+	}
+	users, err := page.After(after)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	next := len(users) > size
+	if next {
+		users = users[:size]
+	}
+	res := &pb.ListUsersResponse{TotalSize: page.TotalItems}
+	for i := range users {
+		res.Users = append(res.Users, userMessage(&users[i]))
+	}
+	if next {
+		res.NextPageToken = page.Token(users[size-1].Id)
+	}
+	return res, nil
+}
@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+	pb "go_app/src/postspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialServer serves a NewServer authorized by token in memory and returns a connection to it.
+func dialServer(t *testing.T, token string) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(grpc.UnaryInterceptor(AuthInterceptor(token)))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "grpc@example.com")
+	if err := m.UpdateUser(userId, map[string]interface{}{"current_sign_in_ip": "192.0.2.1", "last_sign_in_ip": "192.0.2.2"}); err != nil {
+		t.Fatal(err)
+	}
+	client := pb.NewUserServiceClient(dialServer(t, "secret"))
+	req := &pb.GetUserRequest{Id: userId}
+
+	for _, authorization := range []string{"", "Bearer wrong", "secret"} {
+		ctx := context.Background()
+		if authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+		if _, err := client.GetUser(ctx, req); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("GetUser with the authorization %q error = %v, want Unauthenticated", authorization, err)
+		}
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	u, err := client.GetUser(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.CurrentSignInIp != nil || u.LastSignInIp != nil {
+		t.Errorf("GetUser() sign in IPs = %v %v, want unset", u.CurrentSignInIp, u.LastSignInIp)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer ")
+	if _, err := pb.NewUserServiceClient(dialServer(t, "")).GetUser(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("GetUser on a server without a token error = %v, want Unauthenticated", err)
+	}
+}
+
+// TestListNextPageToken checks a full last page has no next_page_token.
+func TestListNextPageToken(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "list@example.com")
+	modeltest.CreateUser(t, "list2@example.com")
+	for i := 0; i < 2; i++ {
+		modeltest.CreatePost(t, userId, "A listed post title", "Some post content here, long enough")
+	}
+	conn := dialServer(t, "secret")
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+
+	posts := pb.NewPostServiceClient(conn)
+	first, err := posts.ListPosts(ctx, &pb.ListPostsRequest{PageSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Posts) != 1 || first.NextPageToken == "" {
+		t.Fatalf("ListPosts first page = %v, want a post and a next_page_token", first)
+	}
+	last, err := posts.ListPosts(ctx, &pb.ListPostsRequest{PageSize: 1, PageToken: first.NextPageToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last.Posts) != 1 || last.NextPageToken != "" {
+		t.Errorf("ListPosts full last page = %v, want a post and no next_page_token", last)
+	}
+
+	users, err := pb.NewUserServiceClient(conn).ListUsers(ctx, &pb.ListUsersRequest{PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users.Users) != 2 || users.NextPageToken != "" {
+		t.Errorf("ListUsers full last page = %v, want 2 users and no next_page_token", users)
+	}
+}
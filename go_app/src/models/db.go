@@ -1,26 +1,52 @@
 package models
 
 import (
-	"log"
+	"fmt"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
 )
 
+// DB is the primary database, it's connected by Open.
 var DB *sqlx.DB
 
-func init() {
-	var err error
-	driver_name := "mysql"
-	if driver_name == "" {
-		log.Fatal("Invalid driver name")
+// Open connects DB to a database with one of the supported drivers: mysql, pgx (postgres) or sqlite3.
+func Open(driverName, dsn string) error {
+	d, ok := dialects[driverName]
+	if !ok {
+		return fmt.Errorf("Invalid driver name: %s", driverName)
 	}
-	dsn := "root:@tcp(localhost:3306)/simple_example_with_admin_development?charset=utf8&parseTime=True&loc=Local"
-	if dsn == "" {
-		log.Fatal("Invalid DSN")
+	db, err := connect(driverName, dsn)
+	if err != nil {
+		return err
+	}
+	// the statements cached on a previous DB would never be used again
+	if DB != nil {
+		purgeStmts(DB)
 	}
-	DB, err = sqlx.Connect(driver_name, dsn)
+	DB, dialect = db, d
+	return nil
+}
+
+// connect opens and pings a database with the queries reported to the QueryObservers.
+func connect(driverName, dsn string) (*sqlx.DB, error) {
+	sqlDB, err := openObserved(driverName, dsn)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	db := sqlx.NewDb(sqlDB, driverName)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Close closes the read replicas, the cached prepared statements and DB, it's called on shutdown.
+func Close() error {
+	CloseReplicas()
+	CloseStmtCache()
+	return DB.Close()
 }
@@ -0,0 +1,260 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Dialect hides the SQL differences between the supported databases.
+type Dialect interface {
+	// Name is the name of the database/sql driver.
+	Name() string
+	// Quote quotes an identifier like a table or column name.
+	Quote(ident string) string
+	// Returning reports whether the inserted id is read with INSERT ... RETURNING id
+	// instead of sql.Result.LastInsertId.
+	Returning() bool
+	// Upsert builds an INSERT of the named params of cols into table, which updates
+	// the updateCols instead when a row with the same key column already exists.
+	Upsert(table string, cols, updateCols []string, key string) string
+	// Limit builds the LIMIT/OFFSET clause, a zero offset is left out.
+	Limit(limit, offset int) string
+	// Now is the current time truncated to the precision the timestamps are stored with.
+	Now() time.Time
+	// Bool is the param value of a boolean, as the Rails adapter of the database stores it.
+	Bool(b bool) interface{}
+	// Columns introspects the columns of a table.
+	Columns(table string) ([]Column, error)
+	// TranslateError maps a driver error, wrapped or not, to ErrDuplicate or ErrForeignKey, nil if it's neither.
+	TranslateError(err error) error
+}
+
+// dialects are the supported dialects by driver name.
+var dialects = map[string]Dialect{
+	"mysql":    mysqlDialect{},
+	"pgx":      postgresDialect{},
+	"postgres": postgresDialect{},
+	"sqlite3":  sqliteDialect{},
+}
+
+// dialect is the Dialect of DB, it's set by Open.
+var dialect Dialect = mysqlDialect{}
+
+// CurrentDialect returns the Dialect of the connected database.
+func CurrentDialect() Dialect {
+	return dialect
+}
+
+// now is the time used for the created_at/updated_at columns.
+func now() time.Time {
+	return dialect.Now()
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Quote(ident string) string {
+	return "`" + strings.Replace(ident, "`", "``", -1) + "`"
+}
+
+func (mysqlDialect) Returning() bool { return false }
+
+func (d mysqlDialect) Upsert(table string, cols, updateCols []string, key string) string {
+	sets := make([]string, len(updateCols))
+	for i, c := range updateCols {
+		sets[i] = fmt.Sprintf("%s = VALUES(%s)", d.Quote(c), d.Quote(c))
+	}
+	return insertSQL(d, table, cols) + " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+func (mysqlDialect) Limit(limit, offset int) string {
+	return limitSQL(limit, offset)
+}
+
+// Now is truncated to seconds, the precision of the DATETIME columns created by Rails.
+func (mysqlDialect) Now() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+// Bool is left to the driver, it sends the booleans as the 1/0 of the TINYINT(1) columns.
+func (mysqlDialect) Bool(b bool) interface{} { return b }
+
+func (mysqlDialect) Columns(table string) ([]Column, error) {
+	cols := []Column{}
+	err := DB.Select(&cols, `SELECT COLUMN_NAME AS name, DATA_TYPE AS data_type, COLUMN_TYPE AS column_type,
+		IS_NULLABLE = 'YES' AS nullable, COLUMN_DEFAULT AS default_value,
+		CHARACTER_MAXIMUM_LENGTH AS char_max_length, CHARACTER_OCTET_LENGTH AS byte_max_length, EXTRA AS extra
+		FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ORDINAL_POSITION`, table)
+	return cols, err
+}
+
+// MySQL server error numbers of the duplicate key and foreign key errors.
+const (
+	mysqlErrDupEntry         = 1062
+	mysqlErrNoReferencedRow  = 1216
+	mysqlErrRowIsReferenced  = 1217
+	mysqlErrRowIsReferenced2 = 1451
+	mysqlErrNoReferencedRow2 = 1452
+)
+
+func (mysqlDialect) TranslateError(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case mysqlErrDupEntry:
+			return ErrDuplicate
+		case mysqlErrNoReferencedRow, mysqlErrRowIsReferenced, mysqlErrRowIsReferenced2, mysqlErrNoReferencedRow2:
+			return ErrForeignKey
+		}
+	}
+	return nil
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "pgx" }
+
+func (postgresDialect) Quote(ident string) string {
+	return `"` + strings.Replace(ident, `"`, `""`, -1) + `"`
+}
+
+func (postgresDialect) Returning() bool { return true }
+
+func (d postgresDialect) Upsert(table string, cols, updateCols []string, key string) string {
+	return insertSQL(d, table, cols) + onConflictSQL(d, updateCols, key)
+}
+
+func (postgresDialect) Limit(limit, offset int) string {
+	return limitSQL(limit, offset)
+}
+
+// Now is truncated to microseconds, the precision of the timestamp columns.
+func (postgresDialect) Now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+func (postgresDialect) Bool(b bool) interface{} { return b }
+
+func (postgresDialect) Columns(table string) ([]Column, error) {
+	cols := []Column{}
+	err := DB.Select(&cols, `SELECT column_name AS name, data_type, udt_name AS column_type,
+		is_nullable = 'YES' AS nullable, column_default AS default_value,
+		character_maximum_length AS char_max_length, character_octet_length AS byte_max_length,
+		CASE WHEN column_default LIKE 'nextval(%' THEN 'auto_increment' ELSE '' END AS extra
+		FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position`, table)
+	return cols, err
+}
+
+// TranslateError checks the SQLSTATE of the pgx errors, unique_violation and foreign_key_violation.
+func (postgresDialect) TranslateError(err error) error {
+	var pe interface{ SQLState() string }
+	if errors.As(err, &pe) {
+		switch pe.SQLState() {
+		case "23505":
+			return ErrDuplicate
+		case "23503":
+			return ErrForeignKey
+		}
+	}
+	return nil
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite3" }
+
+func (sqliteDialect) Quote(ident string) string {
+	return `"` + strings.Replace(ident, `"`, `""`, -1) + `"`
+}
+
+func (sqliteDialect) Returning() bool { return false }
+
+func (d sqliteDialect) Upsert(table string, cols, updateCols []string, key string) string {
+	return insertSQL(d, table, cols) + onConflictSQL(d, updateCols, key)
+}
+
+func (sqliteDialect) Limit(limit, offset int) string {
+	return limitSQL(limit, offset)
+}
+
+// Now is in UTC, the driver stores the times as text without converting them.
+func (sqliteDialect) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Bool is 't' or 'f', the sqlite3 adapter of Rails 5.1 stores the booleans as text.
+// The driver scans them back to bool like 1 and 0.
+func (sqliteDialect) Bool(b bool) interface{} {
+	if b {
+		return "t"
+	}
+	return "f"
+}
+
+// Columns reads PRAGMA table_info, the lengths are parsed from the declared types, e.g. varchar(255).
+func (sqliteDialect) Columns(table string) ([]Column, error) {
+	infos := []struct {
+		Cid     int            `db:"cid"`
+		Name    string         `db:"name"`
+		Type    string         `db:"type"`
+		NotNull bool           `db:"notnull"`
+		Default sql.NullString `db:"dflt_value"`
+		Pk      int            `db:"pk"`
+	}{}
+	if err := DB.Select(&infos, fmt.Sprintf("PRAGMA table_info(%s)", sqliteDialect{}.Quote(table))); err != nil {
+		return nil, err
+	}
+	cols := make([]Column, len(infos))
+	for i, info := range infos {
+		colType := strings.ToLower(info.Type)
+		c := Column{Name: info.Name, ColumnType: colType, DataType: colType, Nullable: !info.NotNull, Default: info.Default}
+		var n int64
+		if p := strings.Index(colType, "("); p > 0 {
+			c.DataType = colType[:p]
+			if _, err := fmt.Sscanf(colType[p:], "(%d)", &n); err == nil {
+				c.CharMaxLength.Int64, c.CharMaxLength.Valid = n, true
+			}
+		}
+		if info.Pk > 0 {
+			c.Extra = "auto_increment"
+		}
+		cols[i] = c
+	}
+	return cols, nil
+}
+
+// TranslateError checks the extended codes of the sqlite constraint errors, see translateSQLiteError.
+func (sqliteDialect) TranslateError(err error) error {
+	return translateSQLiteError(err)
+}
+
+// insertSQL builds an INSERT of the named params of cols into table.
+func insertSQL(d Dialect, table string, cols []string) string {
+	quoted := make([]string, len(cols))
+	for i, c := range cols {
+		quoted[i] = d.Quote(c)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (:%s)", d.Quote(table), strings.Join(quoted, ","), strings.Join(cols, ",:"))
+}
+
+// onConflictSQL is the upsert clause shared by PostgreSQL and SQLite.
+func onConflictSQL(d Dialect, updateCols []string, key string) string {
+	sets := make([]string, len(updateCols))
+	for i, c := range updateCols {
+		sets[i] = fmt.Sprintf("%s = EXCLUDED.%s", d.Quote(c), d.Quote(c))
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", d.Quote(key), strings.Join(sets, ", "))
+}
+
+func limitSQL(limit, offset int) string {
+	if offset > 0 {
+		return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	}
+	return fmt.Sprintf(" LIMIT %d", limit)
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DriftKind is the kind of a difference between a model struct and its table.
+type DriftKind string
+
+const (
+	// DriftMissingColumn is a struct field whose column isn't in the table.
+	DriftMissingColumn DriftKind = "missing_column"
+	// DriftExtraColumn is a table column no struct field maps to.
+	DriftExtraColumn DriftKind = "extra_column"
+	// DriftType is a field whose Go type can't hold the values of its column.
+	DriftType DriftKind = "type_mismatch"
+	// DriftNullability is a field nullable in Go but not in the table, or the reverse.
+	DriftNullability DriftKind = "nullability_mismatch"
+	// DriftValidation is a validation tag allowing values the column can't store.
+	DriftValidation DriftKind = "validation_mismatch"
+)
+
+// Drift is a difference between the db tagged fields of a model struct and the live table.
+type Drift struct {
+	Model   string
+	Table   string
+	Column  string
+	Kind    DriftKind
+	Message string
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s (%s.%s) %s: %s", d.Model, d.Table, d.Column, d.Kind, d.Message)
+}
+
+// SchemaDrift introspects the tables of the registered models and compares them to the db
+// tagged fields of the structs and their valid tags. An empty result means no drift.
+func SchemaDrift() ([]Drift, error) {
+	models := make([]string, 0, len(modelTypes))
+	for model := range modelTypes {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	drifts := []Drift{}
+	for _, model := range models {
+		cols, err := TableColumns(modelTables[model])
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, modelDrift(model, modelTables[model], modelTypes[model], cols)...)
+	}
+	return drifts, nil
+}
+
+func modelDrift(model, table string, t reflect.Type, cols []Column) (drifts []Drift) {
+	add := func(col string, kind DriftKind, format string, args ...interface{}) {
+		drifts = append(drifts, Drift{Model: model, Table: table, Column: col, Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+	byName := map[string]Column{}
+	for _, c := range cols {
+		byName[c.Name] = c
+	}
+	fields := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("db")
+		kind, nullable := goKind(f.Type)
+		if name == "" || name == "-" || kind == "" {
+			// not a column, e.g. an association
+			continue
+		}
+		fields[name] = true
+		c, ok := byName[name]
+		if !ok {
+			add(name, DriftMissingColumn, "field %s has no column", f.Name)
+			continue
+		}
+		ck := columnKind(c)
+		if ck != "" && !kindsMatch(kind, ck) {
+			add(name, DriftType, "field %s is %s, the column is %s", f.Name, f.Type, c.ColumnType)
+		}
+		if c.Nullable && !nullable {
+			add(name, DriftNullability, "field %s is %s, the column is nullable, scanning a NULL fails", f.Name, f.Type)
+		} else if !c.Nullable && nullable {
+			add(name, DriftNullability, "field %s is %s, the column is NOT NULL", f.Name, f.Type)
+		}
+		if max := tagMaxLength(f.Tag.Get("valid")); max > 0 && c.CharMaxLength.Valid && ck == "string" &&
+			!strings.Contains(c.DataType, "text") && int64(max) > c.CharMaxLength.Int64 {
+			add(name, DriftValidation, "field %s allows %d characters, the column %d", f.Name, max, c.CharMaxLength.Int64)
+		}
+	}
+	for _, c := range cols {
+		if !fields[c.Name] {
+			add(c.Name, DriftExtraColumn, "column %s has no field", c.ColumnType)
+		}
+	}
+	return drifts
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// goKind classifies the Go type of a field as a column kind, the type of a Null is the type of
+// its value. The kind is empty for the types no column maps to, like the associations.
+func goKind(t reflect.Type) (kind string, nullable bool) {
+	if t.Kind() == reflect.Ptr {
+		kind, _ = goKind(t.Elem())
+		return kind, true
+	}
+	if t == timeType {
+		return "time", false
+	}
+	if t.Kind() == reflect.Struct && strings.HasPrefix(t.Name(), "Null[") && t.Implements(valuerType) {
+		if v, ok := t.FieldByName("V"); ok {
+			kind, _ = goKind(v.Type)
+			return kind, true
+		}
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", false
+	case reflect.Float32, reflect.Float64:
+		return "float", false
+	case reflect.Bool:
+		return "boolean", false
+	case reflect.String:
+		return "string", false
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "binary", false
+		}
+	}
+	return "", false
+}
+
+// columnKind classifies the data type of a column, it's empty for the types not checked.
+func columnKind(c Column) string {
+	t := strings.ToLower(c.DataType)
+	switch {
+	case t == "bool" || t == "boolean" || c.ColumnType == "tinyint(1)":
+		return "boolean"
+	case strings.Contains(t, "int") && t != "interval":
+		return "integer"
+	case strings.Contains(t, "char") || strings.Contains(t, "text") || strings.Contains(t, "clob") ||
+		t == "enum" || t == "set" || t == "json" || t == "jsonb" || t == "uuid":
+		return "string"
+	case strings.Contains(t, "date") || strings.Contains(t, "time"):
+		return "time"
+	case t == "float" || t == "double" || t == "real" || t == "decimal" || t == "numeric" || strings.HasPrefix(t, "double"):
+		return "float"
+	case strings.Contains(t, "blob") || strings.Contains(t, "binary") || t == "bytea":
+		return "binary"
+	}
+	return ""
+}
+
+// kindsMatch tells whether a field of the Go kind can hold the values of a column of the column kind.
+func kindsMatch(goKind, colKind string) bool {
+	switch {
+	case goKind == colKind:
+		return true
+	case goKind == "boolean" && colKind == "integer", goKind == "integer" && colKind == "boolean":
+		return true
+	case goKind == "string" && colKind == "binary", goKind == "binary" && colKind == "string":
+		return true
+	}
+	return false
+}
+
+// tagMaxLength returns the maximum of the length validator of a valid tag, 0 if there's none.
+func tagMaxLength(tag string) int {
+	for _, opt := range splitTag(tag) {
+		m := tagParamRegexp.FindStringSubmatch(opt)
+		if m == nil || (m[1] != "length" && m[1] != "runelength" && m[1] != "stringlength") {
+			continue
+		}
+		if bounds := strings.SplitN(m[2], "|", 2); len(bounds) == 2 {
+			max, _ := strconv.Atoi(bounds[1])
+			return max
+		}
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package models_test
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	m "go_app/src/models"
+)
+
+// driftPost is a model struct of the kinds of fields checked by the drift, and of associations skipped.
+type driftPost struct {
+	Id        int64          `db:"id"`
+	Title     m.Null[string] `db:"title" valid:"length(0|255)"`
+	Published bool           `db:"published"`
+	CreatedAt time.Time      `db:"created_at"`
+	User      m.User         `db:"user" valid:"-"`
+	Comments  []m.Post       `db:"comments" valid:"-"`
+}
+
+// driftColumns are the columns of driftPost as SQLite reports them.
+func driftColumns() []m.Column {
+	return []m.Column{
+		{Name: "id", DataType: "integer", ColumnType: "integer"},
+		{Name: "title", DataType: "varchar", ColumnType: "varchar(255)", Nullable: true, CharMaxLength: sql.NullInt64{Int64: 255, Valid: true}},
+		{Name: "published", DataType: "boolean", ColumnType: "boolean"},
+		{Name: "created_at", DataType: "datetime", ColumnType: "datetime"},
+	}
+}
+
+func TestModelDrift(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(cols []m.Column) []m.Column
+		want   []string
+	}{
+		{"none", nil, []string{}},
+		{"missing column", func(cols []m.Column) []m.Column { return append(cols[:2], cols[3:]...) },
+			[]string{"published missing_column"}},
+		{"extra column", func(cols []m.Column) []m.Column {
+			return append(cols, m.Column{Name: "user_id", DataType: "integer", ColumnType: "integer", Nullable: true})
+		}, []string{"user_id extra_column"}},
+		{"type mismatch", func(cols []m.Column) []m.Column {
+			cols[3].DataType, cols[3].ColumnType = "varchar", "varchar(255)"
+			return cols
+		}, []string{"created_at type_mismatch"}},
+		{"boolean of tinyint(1)", func(cols []m.Column) []m.Column {
+			cols[2].DataType, cols[2].ColumnType = "tinyint", "tinyint(1)"
+			return cols
+		}, []string{}},
+		{"nullable column", func(cols []m.Column) []m.Column { cols[3].Nullable = true; return cols },
+			[]string{"created_at nullability_mismatch"}},
+		{"not null column", func(cols []m.Column) []m.Column { cols[1].Nullable = false; return cols },
+			[]string{"title nullability_mismatch"}},
+		{"validation", func(cols []m.Column) []m.Column { cols[1].CharMaxLength.Int64 = 100; return cols },
+			[]string{"title validation_mismatch"}},
+		{"validation of a text", func(cols []m.Column) []m.Column {
+			cols[1].DataType, cols[1].ColumnType, cols[1].CharMaxLength.Int64 = "text", "text", 100
+			return cols
+		}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols := driftColumns()
+			if tt.change != nil {
+				cols = tt.change(cols)
+			}
+			drifts := m.ModelDrift("DriftPost", "drift_posts", reflect.TypeOf(driftPost{}), cols)
+			got := []string{}
+			for _, d := range drifts {
+				if d.Model != "DriftPost" || d.Table != "drift_posts" {
+					t.Errorf("drift %s isn't of DriftPost (drift_posts)", d)
+				}
+				got = append(got, fmt.Sprintf("%s %s", d.Column, d.Kind))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("drifts = %v, want %v", drifts, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The error set returned by the model functions, test them with errors.Is:
+//
+//	if errors.Is(err, models.ErrNotFound) { ... }
+var (
+	ErrValidation       = errors.New("validation failed")
+	ErrNotFound   error = notFoundError{}
+	ErrDuplicate        = errors.New("duplicate record")
+	ErrForeignKey       = errors.New("foreign key constraint failed")
+)
+
+// notFoundError is the type of ErrNotFound, it wraps sql.ErrNoRows so the callers
+// checking errors.Is(err, sql.ErrNoRows) keep working.
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "record not found" }
+
+func (notFoundError) Unwrap() error { return sql.ErrNoRows }
+
+// FieldError describes why a single field of a model is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a model or an attributes map fails validation,
+// it holds the details of every invalid field and matches ErrValidation.
+type ValidationError struct {
+	Model  string
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return fmt.Sprintf("Validate %s error: %s", e.Model, strings.Join(msgs, "; "))
+}
+
+// Is makes errors.Is(err, ErrValidation) true for a *ValidationError.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// newValidationError builds a ValidationError with a single invalid field.
+func newValidationError(model, field, msg string) error {
+	return &ValidationError{Model: model, Errors: []FieldError{{Field: field, Message: msg}}}
+}
+
+// checkColumns makes sure all the keys of an attributes map are columns of the model table.
+func checkColumns(model string, columns []string, am map[string]interface{}) error {
+	known := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		known[c] = true
+	}
+	ve := &ValidationError{Model: model}
+	keys := allKeys(am)
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !known[k] {
+			ve.Errors = append(ve.Errors, FieldError{Field: k, Message: "unknown column"})
+		}
+	}
+	if len(ve.Errors) > 0 {
+		return ve
+	}
+	return nil
+}
+
+// translateError maps the driver errors to the error set of the models package,
+// the original error is kept in the message. Unknown errors are returned as is.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if kind := dialect.TranslateError(err); kind != nil {
+		return fmt.Errorf("%w: %v", kind, err)
+	}
+	return err
+}
@@ -0,0 +1,19 @@
+package models
+
+import "sync/atomic"
+
+// SetReplicasHealthy marks the replicas healthy or not, as their health checks do.
+func SetReplicasHealthy(healthy bool) {
+	replicas.RLock()
+	defer replicas.RUnlock()
+	for _, r := range replicas.pool {
+		v := int32(0)
+		if healthy {
+			v = 1
+		}
+		atomic.StoreInt32(&r.healthy, v)
+	}
+}
+
+// ModelDrift compares a model struct type to the columns of its table.
+var ModelDrift = modelDrift
@@ -1,710 +1,329 @@
-
+// Code generated by gorgen from ../db/schema.rb; DO NOT EDIT.
 
 // Package models includes the functions on the model Post.
 package models
 
 import (
-	"errors"
-	"fmt"
-	"log"
-	"math"
-	"strings"
+	"context"
 	"time"
-
-	"github.com/asaskevich/govalidator"
 )
 
-// set flags to output more detailed log
-func init() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-}
-
 type Post struct {
-	Id int64 `json:"id,omitempty" db:"id" valid:"-"`
-Title string `json:"title,omitempty" db:"title" valid:"required,length(10|50)"`
-Content string `json:"content,omitempty" db:"content" valid:"required,length(20|4294967295)"`
-UserId int64 `json:"user_id,omitempty" db:"user_id" valid:"-"`
-CreatedAt time.Time `json:"created_at,omitempty" db:"created_at" valid:"-"`
-UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at" valid:"-"`
-User User `json:"user,omitempty" db:"user" valid:"-"`
-}
-
-// DataStruct for the pagination
-type PostPage struct {
-	WhereString string
-	WhereParams []interface{}
-	Order       map[string]string
-	FirstId     int64
-	LastId      int64
-	PageNum     int
-	PerPage     int
-	TotalPages  int
-	TotalItems  int64
-	orderStr    string
-}
-
-// Current get the current page of PostPage object for pagination.
-func (_p *PostPage) Current() ([]Post, error) {
-	if _, exist := _p.Order["id"]; !exist {
-		return nil, errors.New("No id order specified in Order map")
-	}
-	err := _p.buildPageCount()
-	if err != nil {
-		return nil, fmt.Errorf("Calculate page count error: %v", err)
-	}
-	if _p.orderStr == "" {
-		_p.buildOrder()
-	}
-	idStr, idParams := _p.buildIdRestrict("current")
-	whereStr := fmt.Sprintf("%s %s %s LIMIT %v", _p.WhereString, idStr, _p.orderStr, _p.PerPage)
-	whereParams := []interface{}{}
-	whereParams = append(append(whereParams, _p.WhereParams...), idParams...)
-	posts, err := FindPostsWhere(whereStr, whereParams...)
-	if err != nil {
-		return nil, err
-	}
-	if len(posts) != 0 {
-		_p.FirstId, _p.LastId = posts[0].Id, posts[len(posts)-1].Id
-	}
-	return posts, nil
+	Id        int64        `json:"id,omitempty" db:"id" valid:"-"`
+	Title     Null[string] `json:"title" db:"title" valid:"required,length(10|50)"`
+	Content   Null[string] `json:"content" db:"content" valid:"required,length(20|65535)"`
+	UserId    Null[int64]  `json:"user_id" db:"user_id" valid:"-"`
+	CreatedAt time.Time    `json:"created_at,omitzero" db:"created_at" valid:"-"`
+	UpdatedAt time.Time    `json:"updated_at,omitzero" db:"updated_at" valid:"-"`
+	User      User         `json:"user,omitempty" db:"user" valid:"-"`
 }
 
-// Previous get the previous page of PostPage object for pagination.
-func (_p *PostPage) Previous() ([]Post, error) {
-	if _p.PageNum == 0 {
-		return nil, errors.New("This's the first page, no previous page yet")
-	}
-	if _, exist := _p.Order["id"]; !exist {
-		return nil, errors.New("No id order specified in Order map")
-	}
-	err := _p.buildPageCount()
-	if err != nil {
-		return nil, fmt.Errorf("Calculate page count error: %v", err)
-	}
-	if _p.orderStr == "" {
-		_p.buildOrder()
-	}
-	idStr, idParams := _p.buildIdRestrict("previous")
-	whereStr := fmt.Sprintf("%s %s %s LIMIT %v", _p.WhereString, idStr, _p.orderStr, _p.PerPage)
-	whereParams := []interface{}{}
-	whereParams = append(append(whereParams, _p.WhereParams...), idParams...)
-	posts, err := FindPostsWhere(whereStr, whereParams...)
-	if err != nil {
-		return nil, err
-	}
-	if len(posts) != 0 {
-		_p.FirstId, _p.LastId = posts[0].Id, posts[len(posts)-1].Id
-	}
-	_p.PageNum -= 1
-	return posts, nil
-}
+// ModelName implements Model.
+func (Post) ModelName() string { return "Post" }
 
-// Next get the next page of PostPage object for pagination.
-func (_p *PostPage) Next() ([]Post, error) {
-	if _p.PageNum == _p.TotalPages-1 {
-		return nil, errors.New("This's the last page, no next page yet")
-	}
-	if _, exist := _p.Order["id"]; !exist {
-		return nil, errors.New("No id order specified in Order map")
-	}
-	err := _p.buildPageCount()
-	if err != nil {
-		return nil, fmt.Errorf("Calculate page count error: %v", err)
-	}
-	if _p.orderStr == "" {
-		_p.buildOrder()
-	}
-	idStr, idParams := _p.buildIdRestrict("next")
-	whereStr := fmt.Sprintf("%s %s %s LIMIT %v", _p.WhereString, idStr, _p.orderStr, _p.PerPage)
-	whereParams := []interface{}{}
-	whereParams = append(append(whereParams, _p.WhereParams...), idParams...)
-	posts, err := FindPostsWhere(whereStr, whereParams...)
-	if err != nil {
-		return nil, err
-	}
-	if len(posts) != 0 {
-		_p.FirstId, _p.LastId = posts[0].Id, posts[len(posts)-1].Id
-	}
-	_p.PageNum += 1
-	return posts, nil
-}
-
-// GetPage is a helper function for the PostPage object to return a corresponding page due to
-// the parameter passed in, i.e. one of "previous, current or next".
-func (_p *PostPage) GetPage(direction string) (ps []Post, err error) {
-	switch direction {
-	case "previous":
-		ps, _ = _p.Previous()
-	case "next":
-		ps, _ = _p.Next()
-	case "current":
-		ps, _ = _p.Current()
-	default:
-		return nil, errors.New("Error: wrong dircetion! None of previous, current or next!")
-	}
-	return
-}
+// TableName implements Model.
+func (Post) TableName() string { return "posts" }
 
-// buildOrder is for PostPage object to build a SQL ORDER BY clause.
-func (_p *PostPage) buildOrder() {
-	tempList := []string{}
-	for k, v := range _p.Order {
-		tempList = append(tempList, fmt.Sprintf("%v %v", k, v))
-	}
-	_p.orderStr = " ORDER BY " + strings.Join(tempList, ", ")
-}
-
-// buildIdRestrict is for PostPage object to build a SQL clause for ID restriction,
-// implementing a simple keyset style pagination.
-func (_p *PostPage) buildIdRestrict(direction string) (idStr string, idParams []interface{}) {
-	switch direction {
-	case "previous":
-		if strings.ToLower(_p.Order["id"]) == "desc" {
-			idStr += "id > ? "
-			idParams = append(idParams, _p.FirstId)
-		} else {
-			idStr += "id < ? "
-			idParams = append(idParams, _p.FirstId)
-		}
-	case "current":
-		// trick to make Where function work
-		if _p.PageNum == 0 && _p.FirstId == 0 && _p.LastId == 0 {
-			idStr += "id > ? "
-			idParams = append(idParams, 0)
-		} else {
-			if strings.ToLower(_p.Order["id"]) == "desc" {
-				idStr += "id <= ? AND id >= ? "
-				idParams = append(idParams, _p.FirstId, _p.LastId)
-			} else {
-				idStr += "id >= ? AND id <= ? "
-				idParams = append(idParams, _p.FirstId, _p.LastId)
-			}
-		}
-	case "next":
-		if strings.ToLower(_p.Order["id"]) == "desc" {
-			idStr += "id < ? "
-			idParams = append(idParams, _p.LastId)
-		} else {
-			idStr += "id > ? "
-			idParams = append(idParams, _p.LastId)
-		}
-	}
-	if _p.WhereString != "" {
-		idStr = " AND " + idStr
-	}
-	return
-}
+// PostRepository is the Repository of Post, the functions below are its typed shortcuts.
+var PostRepository = NewRepository[Post]()
 
-// buildPageCount calculate the TotalItems/TotalPages for the PostPage object.
-func (_p *PostPage) buildPageCount() error {
-	count, err := PostCountWhere(_p.WhereString, _p.WhereParams...)
-	if err != nil {
-		return err
-	}
-	_p.TotalItems = count
-	if _p.PerPage == 0 {
-		_p.PerPage = 10
-	}
-	_p.TotalPages = int(math.Ceil(float64(_p.TotalItems) / float64(_p.PerPage)))
-	return nil
+// register the DB-backed validators of Post, the ones of the valid tags are registered by NewRepository
+func init() {
+	RegisterValidator("Post", "user", BelongsTo("users", "user_id"))
 }
 
+// PostPage is a page of Post records for pagination.
+type PostPage = Page[Post]
 
 // FindPost find a single post by an ID.
 func FindPost(id int64) (*Post, error) {
-	if id == 0 {
-		return nil, errors.New("Invalid ID: it can't be zero")
-	}
-	_post := Post{}
-	err := DB.Get(&_post, DB.Rebind(`SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts WHERE posts.id = ? LIMIT 1`), id)
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return &_post, nil
+	return PostRepository.Find(context.Background(), id)
+}
+
+// FindPostContext is FindPost with a context, it reads from a replica unless ctx is marked by Primary.
+func FindPostContext(ctx context.Context, id int64) (*Post, error) {
+	return PostRepository.Find(ctx, id)
 }
 
 // FirstPost find the first one post by ID ASC order.
 func FirstPost() (*Post, error) {
-	_post := Post{}
-	err := DB.Get(&_post, DB.Rebind(`SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts ORDER BY posts.id ASC LIMIT 1`))
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return &_post, nil
+	return PostRepository.First(context.Background())
+}
+
+// FirstPostContext is FirstPost with a context, it reads from a replica unless ctx is marked by Primary.
+func FirstPostContext(ctx context.Context) (*Post, error) {
+	return PostRepository.First(ctx)
 }
 
 // FirstPosts find the first N posts by ID ASC order.
 func FirstPosts(n uint32) ([]Post, error) {
-	_posts := []Post{}
-	sql := fmt.Sprintf("SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts ORDER BY posts.id ASC LIMIT %v", n)
-	err := DB.Select(&_posts, DB.Rebind(sql))
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return _posts, nil
+	return PostRepository.FirstN(context.Background(), n)
+}
+
+// FirstPostsContext is FirstPosts with a context, it reads from a replica unless ctx is marked by Primary.
+func FirstPostsContext(ctx context.Context, n uint32) ([]Post, error) {
+	return PostRepository.FirstN(ctx, n)
 }
 
 // LastPost find the last one post by ID DESC order.
 func LastPost() (*Post, error) {
-	_post := Post{}
-	err := DB.Get(&_post, DB.Rebind(`SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts ORDER BY posts.id DESC LIMIT 1`))
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return &_post, nil
+	return PostRepository.Last(context.Background())
+}
+
+// LastPostContext is LastPost with a context, it reads from a replica unless ctx is marked by Primary.
+func LastPostContext(ctx context.Context) (*Post, error) {
+	return PostRepository.Last(ctx)
 }
 
 // LastPosts find the last N posts by ID DESC order.
 func LastPosts(n uint32) ([]Post, error) {
-	_posts := []Post{}
-	sql := fmt.Sprintf("SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts ORDER BY posts.id DESC LIMIT %v", n)
-	err := DB.Select(&_posts, DB.Rebind(sql))
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return _posts, nil
+	return PostRepository.LastN(context.Background(), n)
+}
+
+// LastPostsContext is LastPosts with a context, it reads from a replica unless ctx is marked by Primary.
+func LastPostsContext(ctx context.Context, n uint32) ([]Post, error) {
+	return PostRepository.LastN(ctx, n)
 }
 
 // FindPosts find one or more posts by the given ID(s).
 func FindPosts(ids ...int64) ([]Post, error) {
-	if len(ids) == 0 {
-		msg := "At least one or more ids needed"
-		log.Println(msg)
-		return nil, errors.New(msg)
-	}
-	_posts := []Post{}
-	idsHolder := strings.Repeat(",?", len(ids)-1)
-	sql := DB.Rebind(fmt.Sprintf(`SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts WHERE posts.id IN (?%s)`, idsHolder))
-	idsT := []interface{}{}
-	for _,id := range ids {
-		idsT = append(idsT, interface{}(id))
-	}
-	err := DB.Select(&_posts, sql, idsT...)
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return _posts, nil
+	return PostRepository.FindMany(context.Background(), ids...)
+}
+
+// FindPostsContext is FindPosts with a context, it reads from a replica unless ctx is marked by Primary.
+func FindPostsContext(ctx context.Context, ids ...int64) ([]Post, error) {
+	return PostRepository.FindMany(ctx, ids...)
 }
 
 // FindPostBy find a single post by a field name and a value.
 func FindPostBy(field string, val interface{}) (*Post, error) {
-	_post := Post{}
-	sqlFmt := `SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts WHERE %s = ? LIMIT 1`
-	sqlStr := fmt.Sprintf(sqlFmt, field)
-	err := DB.Get(&_post, DB.Rebind(sqlStr), val)
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return &_post, nil
+	return PostRepository.FindBy(context.Background(), field, val)
+}
+
+// FindPostByContext is FindPostBy with a context, it reads from a replica unless ctx is marked by Primary.
+func FindPostByContext(ctx context.Context, field string, val interface{}) (*Post, error) {
+	return PostRepository.FindBy(ctx, field, val)
 }
 
 // FindPostsBy find all posts by a field name and a value.
-func FindPostsBy(field string, val interface{}) (_posts []Post, err error) {
-	sqlFmt := `SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts WHERE %s = ?`
-	sqlStr := fmt.Sprintf(sqlFmt, field)
-	err = DB.Select(&_posts, DB.Rebind(sqlStr), val)
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return _posts, nil
+func FindPostsBy(field string, val interface{}) ([]Post, error) {
+	return PostRepository.FindAllBy(context.Background(), field, val)
+}
+
+// FindPostsByContext is FindPostsBy with a context, it reads from a replica unless ctx is marked by Primary.
+func FindPostsByContext(ctx context.Context, field string, val interface{}) ([]Post, error) {
+	return PostRepository.FindAllBy(ctx, field, val)
 }
 
 // AllPosts get all the Post records.
-func AllPosts() (posts []Post, err error) {
-	err = DB.Select(&posts, "SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts")
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return posts, nil
+func AllPosts() ([]Post, error) {
+	return PostRepository.All(context.Background())
+}
+
+// AllPostsContext is AllPosts with a context, it reads from a replica unless ctx is marked by Primary.
+func AllPostsContext(ctx context.Context) ([]Post, error) {
+	return PostRepository.All(ctx)
 }
 
 // PostCount get the count of all the Post records.
-func PostCount() (c int64, err error) {
-	err = DB.Get(&c, "SELECT count(*) FROM posts")
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	return c, nil
+func PostCount() (int64, error) {
+	return PostRepository.Count(context.Background())
+}
+
+// PostCountContext is PostCount with a context, it reads from a replica unless ctx is marked by Primary.
+func PostCountContext(ctx context.Context) (int64, error) {
+	return PostRepository.Count(ctx)
 }
 
 // PostCountWhere get the count of all the Post records with a where clause.
-func PostCountWhere(where string, args ...interface{}) (c int64, err error) {
-	sql := "SELECT count(*) FROM posts"
-	if len(where) > 0 {
-		sql = sql + " WHERE " + where
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	err = stmt.Get(&c, args...)
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	return c, nil
+func PostCountWhere(where string, args ...interface{}) (int64, error) {
+	return PostRepository.CountWhere(context.Background(), where, args...)
+}
+
+// PostCountWhereContext is PostCountWhere with a context, it reads from a replica unless ctx is marked by Primary.
+func PostCountWhereContext(ctx context.Context, where string, args ...interface{}) (int64, error) {
+	return PostRepository.CountWhere(ctx, where, args...)
 }
 
 // PostIncludesWhere get the Post associated models records, currently it's not same as the corresponding "includes" function but "preload" instead in Ruby on Rails. It means that the "sql" should be restricted on Post model.
-func PostIncludesWhere(assocs []string, sql string, args ...interface{}) (_posts []Post, err error) {
-	_posts, err = FindPostsWhere(sql, args...)
+func PostIncludesWhere(assocs []string, sql string, args ...interface{}) ([]Post, error) {
+	return PostIncludesWhereContext(context.Background(), assocs, sql, args...)
+}
+
+// PostIncludesWhereContext is PostIncludesWhere with a context, it reads from a replica unless ctx is marked by Primary.
+func PostIncludesWhereContext(ctx context.Context, assocs []string, sql string, args ...interface{}) ([]Post, error) {
+	_posts, err := PostRepository.Where(ctx, sql, args...)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
+	// no associations to preload, it's a Where
 	if len(assocs) == 0 {
-		log.Println("No associated fields ard specified")
-		return _posts, err
+		return _posts, nil
 	}
 	if len(_posts) <= 0 {
-		return nil, errors.New("No results available")
-	}
-	ids := make([]interface{}, len(_posts))
-	for _, v := range _posts {
-		ids = append(ids, interface{}(v.Id))
+		return nil, ErrNotFound
 	}
 	return _posts, nil
 }
 
 // PostIds get all the IDs of Post records.
-func PostIds() (ids []int64, err error) {
-	err = DB.Select(&ids, "SELECT id FROM posts")
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return ids, nil
+func PostIds() ([]int64, error) {
+	return PostRepository.Ids(context.Background(), "")
+}
+
+// PostIdsContext is PostIds with a context, it reads from a replica unless ctx is marked by Primary.
+func PostIdsContext(ctx context.Context) ([]int64, error) {
+	return PostRepository.Ids(ctx, "")
 }
 
 // PostIdsWhere get all the IDs of Post records by where restriction.
 func PostIdsWhere(where string, args ...interface{}) ([]int64, error) {
-	ids, err := PostIntCol("id", where, args...)
-	return ids, err
+	return PostRepository.Ids(context.Background(), where, args...)
+}
+
+// PostIdsWhereContext is PostIdsWhere with a context, it reads from a replica unless ctx is marked by Primary.
+func PostIdsWhereContext(ctx context.Context, where string, args ...interface{}) ([]int64, error) {
+	return PostRepository.Ids(ctx, where, args...)
 }
 
 // PostIntCol get some int64 typed column of Post by where restriction.
-func PostIntCol(col, where string, args ...interface{}) (intColRecs []int64, err error) {
-	sql := "SELECT " + col + " FROM posts"
-	if len(where) > 0 {
-		sql = sql + " WHERE " + where
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	err = stmt.Select(&intColRecs, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return intColRecs, nil
+func PostIntCol(col, where string, args ...interface{}) ([]int64, error) {
+	return PostRepository.IntCol(context.Background(), col, where, args...)
+}
+
+// PostIntColContext is PostIntCol with a context, it reads from a replica unless ctx is marked by Primary.
+func PostIntColContext(ctx context.Context, col, where string, args ...interface{}) ([]int64, error) {
+	return PostRepository.IntCol(ctx, col, where, args...)
 }
 
 // PostStrCol get some string typed column of Post by where restriction.
-func PostStrCol(col, where string, args ...interface{}) (strColRecs []string, err error) {
-	sql := "SELECT " + col + " FROM posts"
-	if len(where) > 0 {
-		sql = sql + " WHERE " + where
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	err = stmt.Select(&strColRecs, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return strColRecs, nil
+func PostStrCol(col, where string, args ...interface{}) ([]string, error) {
+	return PostRepository.StrCol(context.Background(), col, where, args...)
+}
+
+// PostStrColContext is PostStrCol with a context, it reads from a replica unless ctx is marked by Primary.
+func PostStrColContext(ctx context.Context, col, where string, args ...interface{}) ([]string, error) {
+	return PostRepository.StrCol(ctx, col, where, args...)
 }
 
 // FindPostsWhere query use a partial SQL clause that usually following after WHERE
 // with placeholders, eg: FindUsersWhere("first_name = ? AND age > ?", "John", 18)
 // will return those records in the table "users" whose first_name is "John" and age elder than 18.
-func FindPostsWhere(where string, args ...interface{}) (posts []Post, err error) {
-	sql := "SELECT COALESCE(posts.title, '') AS title, COALESCE(posts.content, '') AS content, COALESCE(posts.user_id, 0) AS user_id, posts.id, posts.created_at, posts.updated_at FROM posts"
-	if len(where) > 0 {
-		sql = sql + " WHERE " + where
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	err = stmt.Select(&posts, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return posts, nil
+func FindPostsWhere(where string, args ...interface{}) ([]Post, error) {
+	return PostRepository.Where(context.Background(), where, args...)
+}
+
+// FindPostsWhereContext is FindPostsWhere with a context, it reads from a replica unless ctx is marked by Primary.
+func FindPostsWhereContext(ctx context.Context, where string, args ...interface{}) ([]Post, error) {
+	return PostRepository.Where(ctx, where, args...)
 }
 
 // FindPostBySql query use a complete SQL clause
 // with placeholders, eg: FindUserBySql("SELECT * FROM users WHERE first_name = ? AND age > ? ORDER BY DESC LIMIT 1", "John", 18)
 // will return only One record in the table "users" whose first_name is "John" and age elder than 18.
 func FindPostBySql(sql string, args ...interface{}) (*Post, error) {
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	_post := &Post{}
-	err = stmt.Get(_post, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return _post, nil
+	return PostRepository.FindOneBySql(context.Background(), sql, args...)
+}
+
+// FindPostBySqlContext is FindPostBySql with a context, it reads from a replica unless ctx is marked by Primary.
+func FindPostBySqlContext(ctx context.Context, sql string, args ...interface{}) (*Post, error) {
+	return PostRepository.FindOneBySql(ctx, sql, args...)
 }
 
 // FindPostsBySql query use a complete SQL clause
 // with placeholders, eg: FindUsersBySql("SELECT * FROM users WHERE first_name = ? AND age > ?", "John", 18)
 // will return those records in the table "users" whose first_name is "John" and age elder than 18.
-func FindPostsBySql(sql string, args ...interface{}) (posts []Post, err error) {
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	err = stmt.Select(&posts, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return posts, nil
+func FindPostsBySql(sql string, args ...interface{}) ([]Post, error) {
+	return PostRepository.FindBySql(context.Background(), sql, args...)
+}
+
+// FindPostsBySqlContext is FindPostsBySql with a context, it reads from a replica unless ctx is marked by Primary.
+func FindPostsBySqlContext(ctx context.Context, sql string, args ...interface{}) ([]Post, error) {
+	return PostRepository.FindBySql(ctx, sql, args...)
 }
 
 // CreatePost use a named params to create a single Post record.
 // A named params is key-value map like map[string]interface{}{"first_name": "John", "age": 23} .
 func CreatePost(am map[string]interface{}) (int64, error) {
-	if len(am) == 0 {
-		return 0, fmt.Errorf("Zero key in the attributes map!")
-	}
-	t := time.Now()
-	for _, v := range []string{"created_at", "updated_at"} {
-		if am[v] == nil {
-			am[v] = t
-		}
-	}
-	keys := allKeys(am)
-	sqlFmt := `INSERT INTO posts (%s) VALUES (%s)`
-	sql := fmt.Sprintf(sqlFmt, strings.Join(keys, ","), ":"+strings.Join(keys, ",:"))
-	result, err := DB.NamedExec(sql, am)
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	lastId, err := result.LastInsertId()
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	return lastId, nil
+	return PostRepository.CreateMap(context.Background(), am)
 }
 
 // Create is a method for Post to create a record.
 func (_post *Post) Create() (int64, error) {
-	ok, err := govalidator.ValidateStruct(_post)
-	if !ok {
-		errMsg := "Validate Post struct error: Unknown error"
-		if err != nil {
-			errMsg = "Validate Post struct error: " + err.Error()
-		}
-		log.Println(errMsg)
-		return 0, errors.New(errMsg)
-	}
-	t := time.Now()
-	_post.CreatedAt = t
-	_post.UpdatedAt = t
-    sql := `INSERT INTO posts (title,content,user_id,created_at,updated_at) VALUES (:title,:content,:user_id,:created_at,:updated_at)`
-    result, err := DB.NamedExec(sql, _post)
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	lastId, err := result.LastInsertId()
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	return lastId, nil
+	return PostRepository.Create(context.Background(), _post)
 }
 
-
-
-// CreateUser is a method for a Post object to create an associated User record.
+// CreateUser is a method for a Post object to create an associated User record,
+// as belongs_to in Rails the new user's id is assigned to UserId but the post isn't saved.
 func (_post *Post) CreateUser(am map[string]interface{}) error {
-	am["post_id"] = _post.Id
-	_, err := CreateUser(am)
-	return err
+	id, err := CreateUser(am)
+	if err != nil {
+		return err
+	}
+	_post.UserId = NewNull(id)
+	return nil
 }
 
 // Destroy is method used for a Post object to be destroyed.
 func (_post *Post) Destroy() error {
 	if _post.Id == 0 {
-		return errors.New("Invalid Id field: it can't be a zero value")
+		return newValidationError("Post", "id", "Invalid Id field: it can't be a zero value")
 	}
-	err := DestroyPost(_post.Id)
-	return err
+	return DestroyPost(_post.Id)
 }
 
 // DestroyPost will destroy a Post record specified by the id parameter.
 func DestroyPost(id int64) error {
-	stmt, err := DB.Preparex(DB.Rebind(`DELETE FROM posts WHERE id = ?`))
-	_, err = stmt.Exec(id)
-	if err != nil {
-		return err
-	}
-	return nil
+	return PostRepository.Destroy(context.Background(), id)
 }
 
 // DestroyPosts will destroy Post records those specified by the ids parameters.
 func DestroyPosts(ids ...int64) (int64, error) {
-	if len(ids) == 0 {
-		msg := "At least one or more ids needed"
-		log.Println(msg)
-		return 0, errors.New(msg)
-	}
-	idsHolder := strings.Repeat(",?", len(ids)-1)
-	sql := fmt.Sprintf(`DELETE FROM posts WHERE id IN (?%s)`, idsHolder)
-	idsT := []interface{}{}
-	for _,id := range ids {
-		idsT = append(idsT, interface{}(id))
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	result, err := stmt.Exec(idsT...)
-	if err != nil {
-		return 0, err
-	}
-	cnt, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-	return cnt, nil
+	return PostRepository.DestroyMany(context.Background(), ids...)
 }
 
 // DestroyPostsWhere delete records by a where clause restriction.
 // e.g. DestroyPostsWhere("name = ?", "John")
 // And this func will not call the association dependent action
 func DestroyPostsWhere(where string, args ...interface{}) (int64, error) {
-	sql := `DELETE FROM posts WHERE `
-	if len(where) > 0 {
-		sql = sql + where
-	} else {
-		return 0, errors.New("No WHERE conditions provided")
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	result, err := stmt.Exec(args...)
-	if err != nil {
-		return 0, err
-	}
-	cnt, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-	return cnt, nil
+	return PostRepository.DestroyWhere(context.Background(), where, args...)
 }
 
-
 // Save method is used for a Post object to update an existed record mainly.
-// If no id provided a new record will be created. FIXME: A UPSERT action will be implemented further.
+// If no id provided a new record will be created, else it's an UPSERT on the id.
 func (_post *Post) Save() error {
-	ok, err := govalidator.ValidateStruct(_post)
-	if !ok {
-		errMsg := "Validate Post struct error: Unknown error"
-		if err != nil {
-			errMsg = "Validate Post struct error: " + err.Error()
-		}
-		log.Println(errMsg)
-		return errors.New(errMsg)
-	}
-	if _post.Id == 0 {
-		_, err = _post.Create()
-		return err
-	}
-	_post.UpdatedAt = time.Now()
-	sqlFmt := `UPDATE posts SET %s WHERE id = %v`
-	sqlStr := fmt.Sprintf(sqlFmt, "title = :title, content = :content, user_id = :user_id, updated_at = :updated_at", _post.Id)
-    _, err = DB.NamedExec(sqlStr, _post)
-    return err
+	return PostRepository.Save(context.Background(), _post)
 }
 
 // UpdatePost is used to update a record with a id and map[string]interface{} typed key-value parameters.
 func UpdatePost(id int64, am map[string]interface{}) error {
-	if len(am) == 0 {
-		return errors.New("Zero key in the attributes map!")
-	}
-	am["updated_at"] = time.Now()
-	keys := allKeys(am)
-	sqlFmt := `UPDATE posts SET %s WHERE id = %v`
-	setKeysArr := []string{}
-	for _,v := range keys {
-		s := fmt.Sprintf(" %s = :%s", v, v)
-		setKeysArr = append(setKeysArr, s)
-	}
-	sqlStr := fmt.Sprintf(sqlFmt, strings.Join(setKeysArr, ", "), id)
-	_, err := DB.NamedExec(sqlStr, am)
-	if err != nil {
-		log.Println(err)
-		return err
-	}
-	return nil
+	return PostRepository.Update(context.Background(), id, am)
 }
 
 // Update is a method used to update a Post record with the map[string]interface{} typed key-value parameters.
 func (_post *Post) Update(am map[string]interface{}) error {
 	if _post.Id == 0 {
-		return errors.New("Invalid Id field: it can't be a zero value")
+		return newValidationError("Post", "id", "Invalid Id field: it can't be a zero value")
 	}
-	err := UpdatePost(_post.Id, am)
-	return err
+	return UpdatePost(_post.Id, am)
 }
 
 // UpdateAttributes method is supposed to be used to update Post records as corresponding update_attributes in Ruby on Rails.
 func (_post *Post) UpdateAttributes(am map[string]interface{}) error {
-	if _post.Id == 0 {
-		return errors.New("Invalid Id field: it can't be a zero value")
-	}
-	err := UpdatePost(_post.Id, am)
-	return err
+	return _post.Update(am)
 }
 
-// UpdateColumns method is supposed to be used to update Post records as corresponding update_columns in Ruby on Rails.
+// UpdateColumns method is supposed to be used to update Post records as corresponding update_columns in Ruby on Rails,
+// so the validations are skipped.
 func (_post *Post) UpdateColumns(am map[string]interface{}) error {
 	if _post.Id == 0 {
-		return errors.New("Invalid Id field: it can't be a zero value")
+		return newValidationError("Post", "id", "Invalid Id field: it can't be a zero value")
 	}
-	err := UpdatePost(_post.Id, am)
-	return err
+	return PostRepository.UpdateColumns(context.Background(), _post.Id, am)
 }
 
 // UpdatePostsBySql is used to update Post records by a SQL clause
 // using the '?' binding syntax.
 func UpdatePostsBySql(sql string, args ...interface{}) (int64, error) {
-	if sql == "" {
-		return 0, errors.New("A blank SQL clause")
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	result, err := stmt.Exec(args...)
-	if err != nil {
-		return 0, err
-	}
-	cnt, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-	return cnt, nil
+	return PostRepository.UpdateBySql(context.Background(), sql, args...)
 }
@@ -1,580 +1,295 @@
-
+// Code generated by gorgen from ../db/schema.rb; DO NOT EDIT.
 
 // Package models includes the functions on the model User.
 package models
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"log"
-	"math"
 	"strings"
 	"time"
-
-	"github.com/asaskevich/govalidator"
 )
 
-// set flags to output more detailed log
-func init() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-}
-
 type User struct {
-	Id int64 `json:"id,omitempty" db:"id" valid:"-"`
-Email string `json:"email,omitempty" db:"email" valid:"required,matches(\A[^@\s]+@[^@\s]+\z)"`
-EncryptedPassword string `json:"encrypted_password,omitempty" db:"encrypted_password" valid:"-"`
-ResetPasswordToken string `json:"reset_password_token,omitempty" db:"reset_password_token" valid:"-"`
-ResetPasswordSentAt time.Time `json:"reset_password_sent_at,omitempty" db:"reset_password_sent_at" valid:"-"`
-RememberCreatedAt time.Time `json:"remember_created_at,omitempty" db:"remember_created_at" valid:"-"`
-SignInCount int64 `json:"sign_in_count,omitempty" db:"sign_in_count" valid:"-"`
-CurrentSignInAt time.Time `json:"current_sign_in_at,omitempty" db:"current_sign_in_at" valid:"-"`
-LastSignInAt time.Time `json:"last_sign_in_at,omitempty" db:"last_sign_in_at" valid:"-"`
-CurrentSignInIp string `json:"current_sign_in_ip,omitempty" db:"current_sign_in_ip" valid:"-"`
-LastSignInIp string `json:"last_sign_in_ip,omitempty" db:"last_sign_in_ip" valid:"-"`
-CreatedAt time.Time `json:"created_at,omitempty" db:"created_at" valid:"-"`
-UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at" valid:"-"`
-Role string `json:"role,omitempty" db:"role" valid:"-"`
-Posts []Post `json:"posts,omitempty" db:"posts" valid:"-"`
-}
-
-// DataStruct for the pagination
-type UserPage struct {
-	WhereString string
-	WhereParams []interface{}
-	Order       map[string]string
-	FirstId     int64
-	LastId      int64
-	PageNum     int
-	PerPage     int
-	TotalPages  int
-	TotalItems  int64
-	orderStr    string
-}
-
-// Current get the current page of UserPage object for pagination.
-func (_p *UserPage) Current() ([]User, error) {
-	if _, exist := _p.Order["id"]; !exist {
-		return nil, errors.New("No id order specified in Order map")
-	}
-	err := _p.buildPageCount()
-	if err != nil {
-		return nil, fmt.Errorf("Calculate page count error: %v", err)
-	}
-	if _p.orderStr == "" {
-		_p.buildOrder()
-	}
-	idStr, idParams := _p.buildIdRestrict("current")
-	whereStr := fmt.Sprintf("%s %s %s LIMIT %v", _p.WhereString, idStr, _p.orderStr, _p.PerPage)
-	whereParams := []interface{}{}
-	whereParams = append(append(whereParams, _p.WhereParams...), idParams...)
-	users, err := FindUsersWhere(whereStr, whereParams...)
-	if err != nil {
-		return nil, err
-	}
-	if len(users) != 0 {
-		_p.FirstId, _p.LastId = users[0].Id, users[len(users)-1].Id
-	}
-	return users, nil
-}
-
-// Previous get the previous page of UserPage object for pagination.
-func (_p *UserPage) Previous() ([]User, error) {
-	if _p.PageNum == 0 {
-		return nil, errors.New("This's the first page, no previous page yet")
-	}
-	if _, exist := _p.Order["id"]; !exist {
-		return nil, errors.New("No id order specified in Order map")
-	}
-	err := _p.buildPageCount()
-	if err != nil {
-		return nil, fmt.Errorf("Calculate page count error: %v", err)
-	}
-	if _p.orderStr == "" {
-		_p.buildOrder()
-	}
-	idStr, idParams := _p.buildIdRestrict("previous")
-	whereStr := fmt.Sprintf("%s %s %s LIMIT %v", _p.WhereString, idStr, _p.orderStr, _p.PerPage)
-	whereParams := []interface{}{}
-	whereParams = append(append(whereParams, _p.WhereParams...), idParams...)
-	users, err := FindUsersWhere(whereStr, whereParams...)
-	if err != nil {
-		return nil, err
-	}
-	if len(users) != 0 {
-		_p.FirstId, _p.LastId = users[0].Id, users[len(users)-1].Id
-	}
-	_p.PageNum -= 1
-	return users, nil
-}
-
-// Next get the next page of UserPage object for pagination.
-func (_p *UserPage) Next() ([]User, error) {
-	if _p.PageNum == _p.TotalPages-1 {
-		return nil, errors.New("This's the last page, no next page yet")
-	}
-	if _, exist := _p.Order["id"]; !exist {
-		return nil, errors.New("No id order specified in Order map")
-	}
-	err := _p.buildPageCount()
-	if err != nil {
-		return nil, fmt.Errorf("Calculate page count error: %v", err)
-	}
-	if _p.orderStr == "" {
-		_p.buildOrder()
-	}
-	idStr, idParams := _p.buildIdRestrict("next")
-	whereStr := fmt.Sprintf("%s %s %s LIMIT %v", _p.WhereString, idStr, _p.orderStr, _p.PerPage)
-	whereParams := []interface{}{}
-	whereParams = append(append(whereParams, _p.WhereParams...), idParams...)
-	users, err := FindUsersWhere(whereStr, whereParams...)
-	if err != nil {
-		return nil, err
-	}
-	if len(users) != 0 {
-		_p.FirstId, _p.LastId = users[0].Id, users[len(users)-1].Id
-	}
-	_p.PageNum += 1
-	return users, nil
-}
-
-// GetPage is a helper function for the UserPage object to return a corresponding page due to
-// the parameter passed in, i.e. one of "previous, current or next".
-func (_p *UserPage) GetPage(direction string) (ps []User, err error) {
-	switch direction {
-	case "previous":
-		ps, _ = _p.Previous()
-	case "next":
-		ps, _ = _p.Next()
-	case "current":
-		ps, _ = _p.Current()
-	default:
-		return nil, errors.New("Error: wrong dircetion! None of previous, current or next!")
-	}
-	return
-}
-
-// buildOrder is for UserPage object to build a SQL ORDER BY clause.
-func (_p *UserPage) buildOrder() {
-	tempList := []string{}
-	for k, v := range _p.Order {
-		tempList = append(tempList, fmt.Sprintf("%v %v", k, v))
-	}
-	_p.orderStr = " ORDER BY " + strings.Join(tempList, ", ")
-}
-
-// buildIdRestrict is for UserPage object to build a SQL clause for ID restriction,
-// implementing a simple keyset style pagination.
-func (_p *UserPage) buildIdRestrict(direction string) (idStr string, idParams []interface{}) {
-	switch direction {
-	case "previous":
-		if strings.ToLower(_p.Order["id"]) == "desc" {
-			idStr += "id > ? "
-			idParams = append(idParams, _p.FirstId)
-		} else {
-			idStr += "id < ? "
-			idParams = append(idParams, _p.FirstId)
-		}
-	case "current":
-		// trick to make Where function work
-		if _p.PageNum == 0 && _p.FirstId == 0 && _p.LastId == 0 {
-			idStr += "id > ? "
-			idParams = append(idParams, 0)
-		} else {
-			if strings.ToLower(_p.Order["id"]) == "desc" {
-				idStr += "id <= ? AND id >= ? "
-				idParams = append(idParams, _p.FirstId, _p.LastId)
-			} else {
-				idStr += "id >= ? AND id <= ? "
-				idParams = append(idParams, _p.FirstId, _p.LastId)
-			}
-		}
-	case "next":
-		if strings.ToLower(_p.Order["id"]) == "desc" {
-			idStr += "id < ? "
-			idParams = append(idParams, _p.LastId)
-		} else {
-			idStr += "id > ? "
-			idParams = append(idParams, _p.LastId)
-		}
-	}
-	if _p.WhereString != "" {
-		idStr = " AND " + idStr
-	}
-	return
-}
-
-// buildPageCount calculate the TotalItems/TotalPages for the UserPage object.
-func (_p *UserPage) buildPageCount() error {
-	count, err := UserCountWhere(_p.WhereString, _p.WhereParams...)
-	if err != nil {
-		return err
-	}
-	_p.TotalItems = count
-	if _p.PerPage == 0 {
-		_p.PerPage = 10
-	}
-	_p.TotalPages = int(math.Ceil(float64(_p.TotalItems) / float64(_p.PerPage)))
-	return nil
+	Id                  int64           `json:"id,omitempty" db:"id" valid:"-"`
+	Email               string          `json:"email,omitempty" db:"email" valid:"required,matches(\\A[^@\\s]+@[^@\\s]+\\z)"`
+	EncryptedPassword   string          `json:"encrypted_password,omitempty" db:"encrypted_password" valid:"-"`
+	ResetPasswordToken  Null[string]    `json:"reset_password_token" db:"reset_password_token" valid:"-"`
+	ResetPasswordSentAt Null[time.Time] `json:"reset_password_sent_at" db:"reset_password_sent_at" valid:"-"`
+	RememberCreatedAt   Null[time.Time] `json:"remember_created_at" db:"remember_created_at" valid:"-"`
+	SignInCount         int64           `json:"sign_in_count,omitempty" db:"sign_in_count" valid:"-"`
+	CurrentSignInAt     Null[time.Time] `json:"current_sign_in_at" db:"current_sign_in_at" valid:"-"`
+	LastSignInAt        Null[time.Time] `json:"last_sign_in_at" db:"last_sign_in_at" valid:"-"`
+	CurrentSignInIp     Null[string]    `json:"current_sign_in_ip" db:"current_sign_in_ip" valid:"-"`
+	LastSignInIp        Null[string]    `json:"last_sign_in_ip" db:"last_sign_in_ip" valid:"-"`
+	CreatedAt           time.Time       `json:"created_at,omitzero" db:"created_at" valid:"-"`
+	UpdatedAt           time.Time       `json:"updated_at,omitzero" db:"updated_at" valid:"-"`
+	Role                Null[string]    `json:"role" db:"role" valid:"-"`
+	Posts               []Post          `json:"posts,omitempty" db:"posts" valid:"-"`
+}
+
+// ModelName implements Model.
+func (User) ModelName() string { return "User" }
+
+// TableName implements Model.
+func (User) TableName() string { return "users" }
+
+// UserRepository is the Repository of User, the functions below are its typed shortcuts.
+var UserRepository = NewRepository[User]()
+
+// register the DB-backed validators of User, the ones of the valid tags are registered by NewRepository
+func init() {
+	RegisterValidator("User", "email", Uniqueness(false))
 }
 
+// UserPage is a page of User records for pagination.
+type UserPage = Page[User]
 
 // FindUser find a single user by an ID.
 func FindUser(id int64) (*User, error) {
-	if id == 0 {
-		return nil, errors.New("Invalid ID: it can't be zero")
-	}
-	_user := User{}
-	err := DB.Get(&_user, DB.Rebind(`SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users WHERE users.id = ? LIMIT 1`), id)
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return &_user, nil
+	return UserRepository.Find(context.Background(), id)
+}
+
+// FindUserContext is FindUser with a context, it reads from a replica unless ctx is marked by Primary.
+func FindUserContext(ctx context.Context, id int64) (*User, error) {
+	return UserRepository.Find(ctx, id)
 }
 
 // FirstUser find the first one user by ID ASC order.
 func FirstUser() (*User, error) {
-	_user := User{}
-	err := DB.Get(&_user, DB.Rebind(`SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users ORDER BY users.id ASC LIMIT 1`))
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return &_user, nil
+	return UserRepository.First(context.Background())
+}
+
+// FirstUserContext is FirstUser with a context, it reads from a replica unless ctx is marked by Primary.
+func FirstUserContext(ctx context.Context) (*User, error) {
+	return UserRepository.First(ctx)
 }
 
 // FirstUsers find the first N users by ID ASC order.
 func FirstUsers(n uint32) ([]User, error) {
-	_users := []User{}
-	sql := fmt.Sprintf("SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users ORDER BY users.id ASC LIMIT %v", n)
-	err := DB.Select(&_users, DB.Rebind(sql))
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return _users, nil
+	return UserRepository.FirstN(context.Background(), n)
+}
+
+// FirstUsersContext is FirstUsers with a context, it reads from a replica unless ctx is marked by Primary.
+func FirstUsersContext(ctx context.Context, n uint32) ([]User, error) {
+	return UserRepository.FirstN(ctx, n)
 }
 
 // LastUser find the last one user by ID DESC order.
 func LastUser() (*User, error) {
-	_user := User{}
-	err := DB.Get(&_user, DB.Rebind(`SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users ORDER BY users.id DESC LIMIT 1`))
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return &_user, nil
+	return UserRepository.Last(context.Background())
+}
+
+// LastUserContext is LastUser with a context, it reads from a replica unless ctx is marked by Primary.
+func LastUserContext(ctx context.Context) (*User, error) {
+	return UserRepository.Last(ctx)
 }
 
 // LastUsers find the last N users by ID DESC order.
 func LastUsers(n uint32) ([]User, error) {
-	_users := []User{}
-	sql := fmt.Sprintf("SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users ORDER BY users.id DESC LIMIT %v", n)
-	err := DB.Select(&_users, DB.Rebind(sql))
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return _users, nil
+	return UserRepository.LastN(context.Background(), n)
+}
+
+// LastUsersContext is LastUsers with a context, it reads from a replica unless ctx is marked by Primary.
+func LastUsersContext(ctx context.Context, n uint32) ([]User, error) {
+	return UserRepository.LastN(ctx, n)
 }
 
 // FindUsers find one or more users by the given ID(s).
 func FindUsers(ids ...int64) ([]User, error) {
-	if len(ids) == 0 {
-		msg := "At least one or more ids needed"
-		log.Println(msg)
-		return nil, errors.New(msg)
-	}
-	_users := []User{}
-	idsHolder := strings.Repeat(",?", len(ids)-1)
-	sql := DB.Rebind(fmt.Sprintf(`SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users WHERE users.id IN (?%s)`, idsHolder))
-	idsT := []interface{}{}
-	for _,id := range ids {
-		idsT = append(idsT, interface{}(id))
-	}
-	err := DB.Select(&_users, sql, idsT...)
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return _users, nil
+	return UserRepository.FindMany(context.Background(), ids...)
+}
+
+// FindUsersContext is FindUsers with a context, it reads from a replica unless ctx is marked by Primary.
+func FindUsersContext(ctx context.Context, ids ...int64) ([]User, error) {
+	return UserRepository.FindMany(ctx, ids...)
 }
 
 // FindUserBy find a single user by a field name and a value.
 func FindUserBy(field string, val interface{}) (*User, error) {
-	_user := User{}
-	sqlFmt := `SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users WHERE %s = ? LIMIT 1`
-	sqlStr := fmt.Sprintf(sqlFmt, field)
-	err := DB.Get(&_user, DB.Rebind(sqlStr), val)
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return &_user, nil
+	return UserRepository.FindBy(context.Background(), field, val)
+}
+
+// FindUserByContext is FindUserBy with a context, it reads from a replica unless ctx is marked by Primary.
+func FindUserByContext(ctx context.Context, field string, val interface{}) (*User, error) {
+	return UserRepository.FindBy(ctx, field, val)
 }
 
 // FindUsersBy find all users by a field name and a value.
-func FindUsersBy(field string, val interface{}) (_users []User, err error) {
-	sqlFmt := `SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users WHERE %s = ?`
-	sqlStr := fmt.Sprintf(sqlFmt, field)
-	err = DB.Select(&_users, DB.Rebind(sqlStr), val)
-	if err != nil {
-		log.Printf("Error: %v\n", err)
-		return nil, err
-	}
-	return _users, nil
+func FindUsersBy(field string, val interface{}) ([]User, error) {
+	return UserRepository.FindAllBy(context.Background(), field, val)
+}
+
+// FindUsersByContext is FindUsersBy with a context, it reads from a replica unless ctx is marked by Primary.
+func FindUsersByContext(ctx context.Context, field string, val interface{}) ([]User, error) {
+	return UserRepository.FindAllBy(ctx, field, val)
 }
 
 // AllUsers get all the User records.
-func AllUsers() (users []User, err error) {
-	err = DB.Select(&users, "SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users")
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return users, nil
+func AllUsers() ([]User, error) {
+	return UserRepository.All(context.Background())
+}
+
+// AllUsersContext is AllUsers with a context, it reads from a replica unless ctx is marked by Primary.
+func AllUsersContext(ctx context.Context) ([]User, error) {
+	return UserRepository.All(ctx)
 }
 
 // UserCount get the count of all the User records.
-func UserCount() (c int64, err error) {
-	err = DB.Get(&c, "SELECT count(*) FROM users")
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	return c, nil
+func UserCount() (int64, error) {
+	return UserRepository.Count(context.Background())
+}
+
+// UserCountContext is UserCount with a context, it reads from a replica unless ctx is marked by Primary.
+func UserCountContext(ctx context.Context) (int64, error) {
+	return UserRepository.Count(ctx)
 }
 
 // UserCountWhere get the count of all the User records with a where clause.
-func UserCountWhere(where string, args ...interface{}) (c int64, err error) {
-	sql := "SELECT count(*) FROM users"
-	if len(where) > 0 {
-		sql = sql + " WHERE " + where
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	err = stmt.Get(&c, args...)
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	return c, nil
+func UserCountWhere(where string, args ...interface{}) (int64, error) {
+	return UserRepository.CountWhere(context.Background(), where, args...)
+}
+
+// UserCountWhereContext is UserCountWhere with a context, it reads from a replica unless ctx is marked by Primary.
+func UserCountWhereContext(ctx context.Context, where string, args ...interface{}) (int64, error) {
+	return UserRepository.CountWhere(ctx, where, args...)
 }
 
 // UserIncludesWhere get the User associated models records, currently it's not same as the corresponding "includes" function but "preload" instead in Ruby on Rails. It means that the "sql" should be restricted on User model.
-func UserIncludesWhere(assocs []string, sql string, args ...interface{}) (_users []User, err error) {
-	_users, err = FindUsersWhere(sql, args...)
+func UserIncludesWhere(assocs []string, sql string, args ...interface{}) ([]User, error) {
+	return UserIncludesWhereContext(context.Background(), assocs, sql, args...)
+}
+
+// UserIncludesWhereContext is UserIncludesWhere with a context, it reads from a replica unless ctx is marked by Primary.
+func UserIncludesWhereContext(ctx context.Context, assocs []string, sql string, args ...interface{}) ([]User, error) {
+	_users, err := UserRepository.Where(ctx, sql, args...)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
+	// no associations to preload, it's a Where
 	if len(assocs) == 0 {
-		log.Println("No associated fields ard specified")
-		return _users, err
+		return _users, nil
 	}
 	if len(_users) <= 0 {
-		return nil, errors.New("No results available")
+		return nil, ErrNotFound
 	}
-	ids := make([]interface{}, len(_users))
+	ids := make([]interface{}, 0, len(_users))
 	for _, v := range _users {
 		ids = append(ids, interface{}(v.Id))
 	}
 	idsHolder := strings.Repeat(",?", len(ids)-1)
 	for _, assoc := range assocs {
 		switch assoc {
-				case "posts":
-							where := fmt.Sprintf("user_id IN (?%s)", idsHolder)
-						_posts, err := FindPostsWhere(where, ids...)
-						if err != nil {
-							log.Printf("Error when query associated objects: %v\n", assoc)
-							continue
-						}
-						for _, vv := range _posts {
-							for i, vvv := range  _users {
-									if vv.UserId == vvv.Id {
-										vvv.Posts = append(vvv.Posts, vv)
-									}
-								_users[i].Posts = vvv.Posts
-						    }
-					    }
+		case "posts":
+			where := fmt.Sprintf("user_id IN (?%s)", idsHolder)
+			_posts, err := FindPostsWhereContext(ctx, where, ids...)
+			if err != nil {
+				return nil, fmt.Errorf("Preload %s error: %w", assoc, err)
+			}
+			for _, vv := range _posts {
+				for i, vvv := range _users {
+					if vv.UserId.Valid && vv.UserId.V == vvv.Id {
+						vvv.Posts = append(vvv.Posts, vv)
+					}
+					_users[i].Posts = vvv.Posts
+				}
+			}
 		}
 	}
 	return _users, nil
 }
 
 // UserIds get all the IDs of User records.
-func UserIds() (ids []int64, err error) {
-	err = DB.Select(&ids, "SELECT id FROM users")
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return ids, nil
+func UserIds() ([]int64, error) {
+	return UserRepository.Ids(context.Background(), "")
+}
+
+// UserIdsContext is UserIds with a context, it reads from a replica unless ctx is marked by Primary.
+func UserIdsContext(ctx context.Context) ([]int64, error) {
+	return UserRepository.Ids(ctx, "")
 }
 
 // UserIdsWhere get all the IDs of User records by where restriction.
 func UserIdsWhere(where string, args ...interface{}) ([]int64, error) {
-	ids, err := UserIntCol("id", where, args...)
-	return ids, err
+	return UserRepository.Ids(context.Background(), where, args...)
+}
+
+// UserIdsWhereContext is UserIdsWhere with a context, it reads from a replica unless ctx is marked by Primary.
+func UserIdsWhereContext(ctx context.Context, where string, args ...interface{}) ([]int64, error) {
+	return UserRepository.Ids(ctx, where, args...)
 }
 
 // UserIntCol get some int64 typed column of User by where restriction.
-func UserIntCol(col, where string, args ...interface{}) (intColRecs []int64, err error) {
-	sql := "SELECT " + col + " FROM users"
-	if len(where) > 0 {
-		sql = sql + " WHERE " + where
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	err = stmt.Select(&intColRecs, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return intColRecs, nil
+func UserIntCol(col, where string, args ...interface{}) ([]int64, error) {
+	return UserRepository.IntCol(context.Background(), col, where, args...)
+}
+
+// UserIntColContext is UserIntCol with a context, it reads from a replica unless ctx is marked by Primary.
+func UserIntColContext(ctx context.Context, col, where string, args ...interface{}) ([]int64, error) {
+	return UserRepository.IntCol(ctx, col, where, args...)
 }
 
 // UserStrCol get some string typed column of User by where restriction.
-func UserStrCol(col, where string, args ...interface{}) (strColRecs []string, err error) {
-	sql := "SELECT " + col + " FROM users"
-	if len(where) > 0 {
-		sql = sql + " WHERE " + where
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	err = stmt.Select(&strColRecs, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return strColRecs, nil
+func UserStrCol(col, where string, args ...interface{}) ([]string, error) {
+	return UserRepository.StrCol(context.Background(), col, where, args...)
+}
+
+// UserStrColContext is UserStrCol with a context, it reads from a replica unless ctx is marked by Primary.
+func UserStrColContext(ctx context.Context, col, where string, args ...interface{}) ([]string, error) {
+	return UserRepository.StrCol(ctx, col, where, args...)
 }
 
 // FindUsersWhere query use a partial SQL clause that usually following after WHERE
 // with placeholders, eg: FindUsersWhere("first_name = ? AND age > ?", "John", 18)
 // will return those records in the table "users" whose first_name is "John" and age elder than 18.
-func FindUsersWhere(where string, args ...interface{}) (users []User, err error) {
-	sql := "SELECT COALESCE(users.reset_password_token, '') AS reset_password_token, COALESCE(users.reset_password_sent_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS reset_password_sent_at, COALESCE(users.remember_created_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS remember_created_at, COALESCE(users.current_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS current_sign_in_at, COALESCE(users.last_sign_in_at, CONVERT_TZ('0001-01-01 00:00:00','+00:00','UTC')) AS last_sign_in_at, COALESCE(users.current_sign_in_ip, '') AS current_sign_in_ip, COALESCE(users.last_sign_in_ip, '') AS last_sign_in_ip, COALESCE(users.role, '') AS role, users.id, users.email, users.encrypted_password, users.sign_in_count, users.created_at, users.updated_at FROM users"
-	if len(where) > 0 {
-		sql = sql + " WHERE " + where
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	err = stmt.Select(&users, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return users, nil
+func FindUsersWhere(where string, args ...interface{}) ([]User, error) {
+	return UserRepository.Where(context.Background(), where, args...)
+}
+
+// FindUsersWhereContext is FindUsersWhere with a context, it reads from a replica unless ctx is marked by Primary.
+func FindUsersWhereContext(ctx context.Context, where string, args ...interface{}) ([]User, error) {
+	return UserRepository.Where(ctx, where, args...)
 }
 
 // FindUserBySql query use a complete SQL clause
 // with placeholders, eg: FindUserBySql("SELECT * FROM users WHERE first_name = ? AND age > ? ORDER BY DESC LIMIT 1", "John", 18)
 // will return only One record in the table "users" whose first_name is "John" and age elder than 18.
 func FindUserBySql(sql string, args ...interface{}) (*User, error) {
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	_user := &User{}
-	err = stmt.Get(_user, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return _user, nil
+	return UserRepository.FindOneBySql(context.Background(), sql, args...)
+}
+
+// FindUserBySqlContext is FindUserBySql with a context, it reads from a replica unless ctx is marked by Primary.
+func FindUserBySqlContext(ctx context.Context, sql string, args ...interface{}) (*User, error) {
+	return UserRepository.FindOneBySql(ctx, sql, args...)
 }
 
 // FindUsersBySql query use a complete SQL clause
 // with placeholders, eg: FindUsersBySql("SELECT * FROM users WHERE first_name = ? AND age > ?", "John", 18)
 // will return those records in the table "users" whose first_name is "John" and age elder than 18.
-func FindUsersBySql(sql string, args ...interface{}) (users []User, err error) {
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	err = stmt.Select(&users, args...)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return users, nil
+func FindUsersBySql(sql string, args ...interface{}) ([]User, error) {
+	return UserRepository.FindBySql(context.Background(), sql, args...)
+}
+
+// FindUsersBySqlContext is FindUsersBySql with a context, it reads from a replica unless ctx is marked by Primary.
+func FindUsersBySqlContext(ctx context.Context, sql string, args ...interface{}) ([]User, error) {
+	return UserRepository.FindBySql(ctx, sql, args...)
 }
 
 // CreateUser use a named params to create a single User record.
 // A named params is key-value map like map[string]interface{}{"first_name": "John", "age": 23} .
 func CreateUser(am map[string]interface{}) (int64, error) {
-	if len(am) == 0 {
-		return 0, fmt.Errorf("Zero key in the attributes map!")
-	}
-	t := time.Now()
-	for _, v := range []string{"created_at", "updated_at"} {
-		if am[v] == nil {
-			am[v] = t
-		}
-	}
-	keys := allKeys(am)
-	sqlFmt := `INSERT INTO users (%s) VALUES (%s)`
-	sql := fmt.Sprintf(sqlFmt, strings.Join(keys, ","), ":"+strings.Join(keys, ",:"))
-	result, err := DB.NamedExec(sql, am)
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	lastId, err := result.LastInsertId()
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	return lastId, nil
+	return UserRepository.CreateMap(context.Background(), am)
 }
 
 // Create is a method for User to create a record.
 func (_user *User) Create() (int64, error) {
-	ok, err := govalidator.ValidateStruct(_user)
-	if !ok {
-		errMsg := "Validate User struct error: Unknown error"
-		if err != nil {
-			errMsg = "Validate User struct error: " + err.Error()
-		}
-		log.Println(errMsg)
-		return 0, errors.New(errMsg)
-	}
-	t := time.Now()
-	_user.CreatedAt = t
-	_user.UpdatedAt = t
-    sql := `INSERT INTO users (email,encrypted_password,reset_password_token,reset_password_sent_at,remember_created_at,sign_in_count,current_sign_in_at,last_sign_in_at,current_sign_in_ip,last_sign_in_ip,created_at,updated_at,role) VALUES (:email,:encrypted_password,:reset_password_token,:reset_password_sent_at,:remember_created_at,:sign_in_count,:current_sign_in_at,:last_sign_in_at,:current_sign_in_ip,:last_sign_in_ip,:created_at,:updated_at,:role)`
-    result, err := DB.NamedExec(sql, _user)
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	lastId, err := result.LastInsertId()
-	if err != nil {
-		log.Println(err)
-		return 0, err
-	}
-	return lastId, nil
+	return UserRepository.Create(context.Background(), _user)
 }
 
 // PostsCreate is used for User to create the associated objects Posts
 func (_user *User) PostsCreate(am map[string]interface{}) error {
-			am["user_id"] = _user.Id
-		_, err := CreatePost(am)
+	am["user_id"] = _user.Id
+	_, err := CreatePost(am)
 	return err
 }
 
@@ -585,172 +300,75 @@ func (_user *User) GetPosts() error {
 	_posts, err := UserGetPosts(_user.Id)
 	if err == nil {
 		_user.Posts = _posts
-    }
-    return err
+	}
+	return err
 }
 
 // UserGetPosts a helper fuction used to get associated objects for UserIncludesWhere().
 func UserGetPosts(id int64) ([]Post, error) {
-			_posts, err := FindPostsBy("user_id", id)
-	return _posts, err
+	return FindPostsBy("user_id", id)
 }
 
-
-
-
 // Destroy is method used for a User object to be destroyed.
 func (_user *User) Destroy() error {
 	if _user.Id == 0 {
-		return errors.New("Invalid Id field: it can't be a zero value")
+		return newValidationError("User", "id", "Invalid Id field: it can't be a zero value")
 	}
-	err := DestroyUser(_user.Id)
-	return err
+	return DestroyUser(_user.Id)
 }
 
 // DestroyUser will destroy a User record specified by the id parameter.
 func DestroyUser(id int64) error {
-	stmt, err := DB.Preparex(DB.Rebind(`DELETE FROM users WHERE id = ?`))
-	_, err = stmt.Exec(id)
-	if err != nil {
-		return err
-	}
-	return nil
+	return UserRepository.Destroy(context.Background(), id)
 }
 
 // DestroyUsers will destroy User records those specified by the ids parameters.
 func DestroyUsers(ids ...int64) (int64, error) {
-	if len(ids) == 0 {
-		msg := "At least one or more ids needed"
-		log.Println(msg)
-		return 0, errors.New(msg)
-	}
-	idsHolder := strings.Repeat(",?", len(ids)-1)
-	sql := fmt.Sprintf(`DELETE FROM users WHERE id IN (?%s)`, idsHolder)
-	idsT := []interface{}{}
-	for _,id := range ids {
-		idsT = append(idsT, interface{}(id))
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	result, err := stmt.Exec(idsT...)
-	if err != nil {
-		return 0, err
-	}
-	cnt, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-	return cnt, nil
+	return UserRepository.DestroyMany(context.Background(), ids...)
 }
 
 // DestroyUsersWhere delete records by a where clause restriction.
 // e.g. DestroyUsersWhere("name = ?", "John")
 // And this func will not call the association dependent action
 func DestroyUsersWhere(where string, args ...interface{}) (int64, error) {
-	sql := `DELETE FROM users WHERE `
-	if len(where) > 0 {
-		sql = sql + where
-	} else {
-		return 0, errors.New("No WHERE conditions provided")
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	result, err := stmt.Exec(args...)
-	if err != nil {
-		return 0, err
-	}
-	cnt, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-	return cnt, nil
+	return UserRepository.DestroyWhere(context.Background(), where, args...)
 }
 
-
 // Save method is used for a User object to update an existed record mainly.
-// If no id provided a new record will be created. FIXME: A UPSERT action will be implemented further.
+// If no id provided a new record will be created, else it's an UPSERT on the id.
 func (_user *User) Save() error {
-	ok, err := govalidator.ValidateStruct(_user)
-	if !ok {
-		errMsg := "Validate User struct error: Unknown error"
-		if err != nil {
-			errMsg = "Validate User struct error: " + err.Error()
-		}
-		log.Println(errMsg)
-		return errors.New(errMsg)
-	}
-	if _user.Id == 0 {
-		_, err = _user.Create()
-		return err
-	}
-	_user.UpdatedAt = time.Now()
-	sqlFmt := `UPDATE users SET %s WHERE id = %v`
-	sqlStr := fmt.Sprintf(sqlFmt, "email = :email, encrypted_password = :encrypted_password, reset_password_token = :reset_password_token, reset_password_sent_at = :reset_password_sent_at, remember_created_at = :remember_created_at, sign_in_count = :sign_in_count, current_sign_in_at = :current_sign_in_at, last_sign_in_at = :last_sign_in_at, current_sign_in_ip = :current_sign_in_ip, last_sign_in_ip = :last_sign_in_ip, updated_at = :updated_at, role = :role", _user.Id)
-    _, err = DB.NamedExec(sqlStr, _user)
-    return err
+	return UserRepository.Save(context.Background(), _user)
 }
 
 // UpdateUser is used to update a record with a id and map[string]interface{} typed key-value parameters.
 func UpdateUser(id int64, am map[string]interface{}) error {
-	if len(am) == 0 {
-		return errors.New("Zero key in the attributes map!")
-	}
-	am["updated_at"] = time.Now()
-	keys := allKeys(am)
-	sqlFmt := `UPDATE users SET %s WHERE id = %v`
-	setKeysArr := []string{}
-	for _,v := range keys {
-		s := fmt.Sprintf(" %s = :%s", v, v)
-		setKeysArr = append(setKeysArr, s)
-	}
-	sqlStr := fmt.Sprintf(sqlFmt, strings.Join(setKeysArr, ", "), id)
-	_, err := DB.NamedExec(sqlStr, am)
-	if err != nil {
-		log.Println(err)
-		return err
-	}
-	return nil
+	return UserRepository.Update(context.Background(), id, am)
 }
 
 // Update is a method used to update a User record with the map[string]interface{} typed key-value parameters.
 func (_user *User) Update(am map[string]interface{}) error {
 	if _user.Id == 0 {
-		return errors.New("Invalid Id field: it can't be a zero value")
+		return newValidationError("User", "id", "Invalid Id field: it can't be a zero value")
 	}
-	err := UpdateUser(_user.Id, am)
-	return err
+	return UpdateUser(_user.Id, am)
 }
 
 // UpdateAttributes method is supposed to be used to update User records as corresponding update_attributes in Ruby on Rails.
 func (_user *User) UpdateAttributes(am map[string]interface{}) error {
-	if _user.Id == 0 {
-		return errors.New("Invalid Id field: it can't be a zero value")
-	}
-	err := UpdateUser(_user.Id, am)
-	return err
+	return _user.Update(am)
 }
 
-// UpdateColumns method is supposed to be used to update User records as corresponding update_columns in Ruby on Rails.
+// UpdateColumns method is supposed to be used to update User records as corresponding update_columns in Ruby on Rails,
+// so the validations are skipped.
 func (_user *User) UpdateColumns(am map[string]interface{}) error {
 	if _user.Id == 0 {
-		return errors.New("Invalid Id field: it can't be a zero value")
+		return newValidationError("User", "id", "Invalid Id field: it can't be a zero value")
 	}
-	err := UpdateUser(_user.Id, am)
-	return err
+	return UserRepository.UpdateColumns(context.Background(), _user.Id, am)
 }
 
 // UpdateUsersBySql is used to update User records by a SQL clause
 // using the '?' binding syntax.
 func UpdateUsersBySql(sql string, args ...interface{}) (int64, error) {
-	if sql == "" {
-		return 0, errors.New("A blank SQL clause")
-	}
-	stmt, err := DB.Preparex(DB.Rebind(sql))
-	result, err := stmt.Exec(args...)
-	if err != nil {
-		return 0, err
-	}
-	cnt, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-	return cnt, nil
+	return UserRepository.UpdateBySql(context.Background(), sql, args...)
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Ping checks DB is reachable.
+func Ping(ctx context.Context) error {
+	return DB.PingContext(ctx)
+}
+
+// MigrationVersion returns the latest version in the schema_migrations table of the Rails app.
+func MigrationVersion(ctx context.Context) (string, error) {
+	var version sql.NullString
+	if err := DB.GetContext(ctx, &version, "SELECT MAX(version) FROM schema_migrations"); err != nil {
+		return "", translateError(err)
+	}
+	return version.String, nil
+}
+
+// CheckSchemaVersion returns an error if the database isn't migrated to SchemaVersion.
+func CheckSchemaVersion(ctx context.Context) error {
+	version, err := MigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version != SchemaVersion {
+		return fmt.Errorf("Schema version mismatch: the database is at %q, the models were generated from %q", version, SchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Locale is the locale of the validation error messages.
+var Locale = "en"
+
+// defaultLocale is the locale of the messages missing in Locale, as the i18n fallbacks of Rails.
+const defaultLocale = "en"
+
+// translations holds the flattened locale keys, e.g. "en.errors.messages.blank".
+// The defaults are the ActiveModel/ActiveRecord English messages, LoadLocales
+// overrides them with the Rails app's config/locales.
+var translations = map[string]string{
+	"en.errors.messages.blank":           "can't be blank",
+	"en.errors.messages.invalid":         "is invalid",
+	"en.errors.messages.taken":           "has already been taken",
+	"en.errors.messages.required":        "must exist",
+	"en.errors.messages.too_short.one":   "is too short (minimum is 1 character)",
+	"en.errors.messages.too_short.other": "is too short (minimum is %{count} characters)",
+	"en.errors.messages.too_long.one":    "is too long (maximum is 1 character)",
+	"en.errors.messages.too_long.other":  "is too long (maximum is %{count} characters)",
+	// not in Rails, used for the byte limits of the TEXT columns
+	"en.errors.messages.too_long_bytes.one":   "is too long (maximum is 1 byte)",
+	"en.errors.messages.too_long_bytes.other": "is too long (maximum is %{count} bytes)",
+}
+
+// LoadLocales loads the translations of all the *.yml files in dir, the files are
+// the Rails locale files, so both stacks show the same messages.
+// It's supposed to be called at startup, before any validation.
+func LoadLocales(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		tree := map[interface{}]interface{}{}
+		if err := yaml.Unmarshal(b, &tree); err != nil {
+			return fmt.Errorf("parse locale file %s: %v", f, err)
+		}
+		flattenTranslations("", tree)
+	}
+	return nil
+}
+
+func flattenTranslations(prefix string, tree map[interface{}]interface{}) {
+	for k, v := range tree {
+		key := fmt.Sprint(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch x := v.(type) {
+		case map[interface{}]interface{}:
+			flattenTranslations(key, x)
+		case string:
+			translations[key] = x
+		}
+	}
+}
+
+// errorMessage looks up the message of an error key in the order used by Rails:
+// activerecord.errors.models.MODEL.attributes.ATTR.KEY, activerecord.errors.models.MODEL.KEY,
+// activerecord.errors.messages.KEY, errors.attributes.ATTR.KEY and errors.messages.KEY, in Locale
+// then in defaultLocale. The key itself is returned if no translation is found.
+func errorMessage(model, attr, key string, count int) string {
+	model = strings.ToLower(model)
+	lookups := []string{
+		"activerecord.errors.models." + model + ".attributes." + attr + "." + key,
+		"activerecord.errors.models." + model + "." + key,
+		"activerecord.errors.messages." + key,
+		"errors.attributes." + attr + "." + key,
+		"errors.messages." + key,
+	}
+	locales := []string{Locale}
+	if Locale != defaultLocale {
+		locales = append(locales, defaultLocale)
+	}
+	for _, locale := range locales {
+		for _, l := range lookups {
+			if msg, ok := translate(locale+"."+l, count); ok {
+				return strings.NewReplacer("%{count}", strconv.Itoa(count), "%{attribute}", attr).Replace(msg)
+			}
+		}
+	}
+	return key
+}
+
+// translate finds a key, the pluralized forms "one" and "other" are chosen by count.
+func translate(key string, count int) (string, bool) {
+	if msg, ok := translations[key]; ok {
+		return msg, true
+	}
+	if count == 1 {
+		if msg, ok := translations[key+".one"]; ok {
+			return msg, true
+		}
+	}
+	msg, ok := translations[key+".other"]
+	return msg, ok
+}
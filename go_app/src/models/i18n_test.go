@@ -0,0 +1,96 @@
+package models_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+)
+
+const frLocale = `fr:
+  errors:
+    messages:
+      blank: "doit être rempli(e)"
+      taken: "n'est pas disponible"
+      too_short:
+        one: "est trop court (au moins un caractère)"
+        other: "est trop court (au moins %{count} caractères)"
+  activerecord:
+    errors:
+      models:
+        post:
+          attributes:
+            content:
+              blank: "ne peut pas être vide"
+`
+
+// validationMessages returns the messages of a ValidationError by field, joined by "; ".
+func validationMessages(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var ve *m.ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("error = %v, want a ValidationError", err)
+	}
+	msgs := map[string][]string{}
+	for _, fe := range ve.Errors {
+		msgs[fe.Field] = append(msgs[fe.Field], fe.Message)
+	}
+	joined := map[string]string{}
+	for field, ms := range msgs {
+		joined[field] = strings.Join(ms, "; ")
+	}
+	return joined
+}
+
+func TestLoadLocales(t *testing.T) {
+	modeltest.Open(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fr.yml"), []byte(frLocale), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LoadLocales(dir); err != nil {
+		t.Fatal(err)
+	}
+	m.Locale = "fr"
+	t.Cleanup(func() { m.Locale = "en" })
+	userId := modeltest.CreateUser(t, "locale@example.com")
+
+	tests := []struct {
+		name   string
+		create func() error
+		field  string
+		want   string
+	}{
+		{"errors.messages", func() error {
+			_, err := m.CreatePost(map[string]interface{}{"title": "", "content": "Some post content here, long enough", "user_id": userId})
+			return err
+		}, "title", "doit être rempli(e)"},
+		{"pluralized", func() error {
+			_, err := m.CreatePost(map[string]interface{}{"title": "Too short", "content": "Some post content here, long enough", "user_id": userId})
+			return err
+		}, "title", "est trop court (au moins 10 caractères)"},
+		{"model attribute", func() error {
+			_, err := m.CreatePost(map[string]interface{}{"title": "A post title", "content": "", "user_id": userId})
+			return err
+		}, "content", "ne peut pas être vide"},
+		{"missing key in the locale", func() error {
+			_, err := m.CreatePost(map[string]interface{}{"title": strings.Repeat("a", 51), "content": "Some post content here, long enough", "user_id": userId})
+			return err
+		}, "title", "is too long (maximum is 50 characters)"},
+		{"uniqueness", func() error {
+			_, err := m.CreateUser(map[string]interface{}{"email": "LOCALE@example.com"})
+			return err
+		}, "email", "n'est pas disponible"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validationMessages(t, tt.create())[tt.field]; got != tt.want {
+				t.Errorf("%s messages = %q, want %q", tt.field, got, tt.want)
+			}
+		})
+	}
+}
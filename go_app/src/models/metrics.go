@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+var (
+	queriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "models_queries_total",
+		Help: "Count of the queries by model, operation and status.",
+	}, []string{"model", "operation", "status"})
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "models_query_duration_seconds",
+		Help:    "Duration of the queries by model and operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"model", "operation"})
+	pageCountDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "models_page_count_duration_seconds",
+		Help:    "Duration of the COUNT(*) queries of the pagination.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"model"})
+	stmtCacheHits = prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "models_stmt_cache_hits_total",
+		Help: "Count of the prepared statements found in the cache.",
+	}, func() float64 { return float64(GetStmtCacheStats().Hits) })
+	stmtCacheMisses = prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "models_stmt_cache_misses_total",
+		Help: "Count of the prepared statements not found in the cache.",
+	}, func() float64 { return float64(GetStmtCacheStats().Misses) })
+	stmtCacheEvictions = prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "models_stmt_cache_evictions_total",
+		Help: "Count of the prepared statements evicted from the cache.",
+	}, func() float64 { return float64(GetStmtCacheStats().Evictions) })
+	stmtCacheSize = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "models_stmt_cache_size",
+		Help: "Count of the prepared statements in the cache.",
+	}, func() float64 { return float64(GetStmtCacheStats().Size) })
+)
+
+// observeQueryMetricsOnce adds the query observer of the metrics once, whatever the count of the
+// registries the metrics are registered on.
+var observeQueryMetricsOnce sync.Once
+
+// dbStatsCollector collects the connection pool stats of DB as it is when collected, so the
+// metrics follow an Open after RegisterMetrics.
+type dbStatsCollector struct{}
+
+func (dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	collectors.NewDBStatsCollector(nil, "primary").Describe(ch)
+}
+
+func (dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	if DB == nil {
+		return
+	}
+	collectors.NewDBStatsCollector(DB.DB, "primary").Collect(ch)
+}
+
+// RegisterMetrics registers the metrics of the models on reg: the connection pool stats of DB,
+// the query counters and durations per model, the pagination count durations and the
+// prepared statement cache counters.
+// The queries are counted from the first call on, calling it again, e.g. on another registry, is safe.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{
+		dbStatsCollector{},
+		queriesTotal,
+		queryDuration,
+		pageCountDuration,
+		stmtCacheHits,
+		stmtCacheMisses,
+		stmtCacheEvictions,
+		stmtCacheSize,
+	} {
+		if err := reg.Register(c); err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+			return err
+		}
+	}
+	observeQueryMetricsOnce.Do(func() { AddQueryObserver(QueryObserverFunc(observeQueryMetrics)) })
+	return nil
+}
+
+var tableRegexp = regexp.MustCompile("(?i)\\b(?:FROM|INTO|UPDATE)\\s+[`\"]?(\\w+)")
+
+func observeQueryMetrics(ctx context.Context, e QueryEvent) {
+	op := "other"
+	if fields := strings.Fields(e.SQL); len(fields) > 0 {
+		op = strings.ToLower(fields[0])
+	}
+	status := "ok"
+	if e.Err != nil {
+		status = "error"
+	}
+	model := queryModel(e.SQL)
+	queriesTotal.WithLabelValues(model, op, status).Inc()
+	queryDuration.WithLabelValues(model, op).Observe(e.Duration.Seconds())
+}
+
+// queryModel returns the model of the table queried, or "other" for the tables of no model.
+func queryModel(query string) string {
+	m := tableRegexp.FindStringSubmatch(query)
+	if m == nil {
+		return "other"
+	}
+	for model, table := range modelTables {
+		if strings.EqualFold(table, m[1]) {
+			return model
+		}
+	}
+	return "other"
+}
+
+// observePageCount records the duration of the page count of a model since start.
+func observePageCount(model string, start time.Time) {
+	pageCountDuration.WithLabelValues(model).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,82 @@
+// Package modeltest connects the models to a SQLite database with the tables of ../db/schema.rb,
+// for the tests of the models and of the packages using them.
+package modeltest
+
+import (
+	"path/filepath"
+	"testing"
+
+	m "go_app/src/models"
+)
+
+// Schema is the SQLite DDL of the tables of ../db/schema.rb, as db:schema:load creates them
+// with the sqlite3 adapter of Rails.
+const Schema = `
+CREATE TABLE "posts" (
+	"id" INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	"title" varchar(255),
+	"content" text,
+	"user_id" integer,
+	"created_at" datetime NOT NULL,
+	"updated_at" datetime NOT NULL
+);
+CREATE TABLE "users" (
+	"id" INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	"email" varchar(255) DEFAULT '' NOT NULL,
+	"encrypted_password" varchar(255) DEFAULT '' NOT NULL,
+	"reset_password_token" varchar(255),
+	"reset_password_sent_at" datetime,
+	"remember_created_at" datetime,
+	"sign_in_count" integer DEFAULT 0 NOT NULL,
+	"current_sign_in_at" datetime,
+	"last_sign_in_at" datetime,
+	"current_sign_in_ip" varchar(255),
+	"last_sign_in_ip" varchar(255),
+	"created_at" datetime NOT NULL,
+	"updated_at" datetime NOT NULL,
+	"role" varchar(255) DEFAULT 'guest'
+);
+CREATE UNIQUE INDEX "index_users_on_email" ON "users" ("email");
+CREATE UNIQUE INDEX "index_users_on_reset_password_token" ON "users" ("reset_password_token");
+CREATE TABLE "schema_migrations" ("version" varchar NOT NULL PRIMARY KEY);
+`
+
+// Open connects the models to a new SQLite database in a temporary directory of t, with the
+// tables of Schema migrated to the schema version of the models. It's closed when t ends.
+func Open(t testing.TB) {
+	t.Helper()
+	if err := m.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("Open database error: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := m.Close(); err != nil {
+			t.Errorf("Close database error: %v", err)
+		}
+	})
+	if _, err := m.DB.Exec(Schema); err != nil {
+		t.Fatalf("Create tables error: %v", err)
+	}
+	if _, err := m.DB.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, m.SchemaVersion); err != nil {
+		t.Fatalf("Insert schema version error: %v", err)
+	}
+}
+
+// CreateUser creates a user of the email, the id is returned.
+func CreateUser(t testing.TB, email string) int64 {
+	t.Helper()
+	id, err := m.CreateUser(map[string]interface{}{"email": email})
+	if err != nil {
+		t.Fatalf("Create user error: %v", err)
+	}
+	return id
+}
+
+// CreatePost creates a post of the user with a valid title and content, the id is returned.
+func CreatePost(t testing.TB, userId int64, title, content string) int64 {
+	t.Helper()
+	id, err := m.CreatePost(map[string]interface{}{"title": title, "content": content, "user_id": userId})
+	if err != nil {
+		t.Fatalf("Create post error: %v", err)
+	}
+	return id
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Null is a value of a nullable column, Valid is false for NULL.
+// It round-trips NULL through Scan and Value, and is marshaled to JSON null.
+// IsZero makes it omitted with the omitzero JSON option.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NewNull returns a valid (non-NULL) Null holding v.
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// Ptr returns a pointer to the value, or nil for NULL.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.V
+	return &v
+}
+
+// IsZero reports whether the value is NULL.
+func (n Null[T]) IsZero() bool {
+	return !n.Valid
+}
+
+// Scan implements the sql.Scanner interface, the conversions are the ones of the sql.NullXXX types.
+func (n *Null[T]) Scan(src interface{}) error {
+	if src == nil {
+		var zero T
+		n.V, n.Valid = zero, false
+		return nil
+	}
+	var err error
+	switch p := interface{}(&n.V).(type) {
+	case sql.Scanner:
+		err = p.Scan(src)
+	case *string:
+		var s sql.NullString
+		err = s.Scan(src)
+		*p = s.String
+	case *int64:
+		var i sql.NullInt64
+		err = i.Scan(src)
+		*p = i.Int64
+	case *int32:
+		var i sql.NullInt32
+		err = i.Scan(src)
+		*p = i.Int32
+	case *float64:
+		var f sql.NullFloat64
+		err = f.Scan(src)
+		*p = f.Float64
+	case *bool:
+		var b sql.NullBool
+		err = b.Scan(src)
+		*p = b.Bool
+	case *time.Time:
+		var t sql.NullTime
+		err = t.Scan(src)
+		*p = t.Time
+	default:
+		err = fmt.Errorf("unsupported Scan of %T into Null[%T]", src, n.V)
+	}
+	n.Valid = err == nil
+	return err
+}
+
+// Value implements the driver.Valuer interface, NULL is a nil value.
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+// MarshalJSON marshals NULL to null and a valid value as T.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+
+// UnmarshalJSON unmarshals null to NULL and any other value as T.
+func (n *Null[T]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		var zero T
+		n.V, n.Valid = zero, false
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryEvent describes a query executed on DB or a replica.
+type QueryEvent struct {
+	SQL   string
+	Args  []interface{} // the values of the SensitiveColumns are redacted
+	Start time.Time
+	// Duration is the time until the result is returned, the rows iteration isn't included.
+	Duration time.Duration
+	// RowsAffected is -1 for the queries returning rows.
+	RowsAffected int64
+	Err          error
+}
+
+// QueryObserver receives an event after every query.
+type QueryObserver interface {
+	ObserveQuery(ctx context.Context, e QueryEvent)
+}
+
+// QueryObserverFunc is a function adapter of QueryObserver.
+type QueryObserverFunc func(ctx context.Context, e QueryEvent)
+
+// ObserveQuery calls f(ctx, e).
+func (f QueryObserverFunc) ObserveQuery(ctx context.Context, e QueryEvent) {
+	f(ctx, e)
+}
+
+var queryObservers struct {
+	sync.RWMutex
+	list []QueryObserver
+}
+
+// AddQueryObserver registers an observer of all the queries.
+func AddQueryObserver(o QueryObserver) {
+	queryObservers.Lock()
+	defer queryObservers.Unlock()
+	queryObservers.list = append(queryObservers.list, o)
+}
+
+// SensitiveColumns are the columns whose values are redacted in the QueryEvent args,
+// they're also left out of the JSON:API and GraphQL types.
+var SensitiveColumns = map[string]bool{
+	"encrypted_password":   true,
+	"reset_password_token": true,
+	"current_sign_in_ip":   true,
+	"last_sign_in_ip":      true,
+}
+
+const redacted = "[REDACTED]"
+
+func observeQuery(ctx context.Context, query string, args []interface{}, start time.Time, rowsAffected int64, err error) {
+	queryObservers.RLock()
+	defer queryObservers.RUnlock()
+	if len(queryObservers.list) == 0 {
+		return
+	}
+	e := QueryEvent{
+		SQL:          query,
+		Args:         redactArgs(query, args),
+		Start:        start,
+		Duration:     time.Since(start),
+		RowsAffected: rowsAffected,
+		Err:          err,
+	}
+	for _, o := range queryObservers.list {
+		o.ObserveQuery(ctx, e)
+	}
+}
+
+var (
+	placeholderRegexp = regexp.MustCompile(`\?|\$\d+`)
+	insertRegexp      = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+\S+\s*\(([^)]*)\)\s*VALUES\s*\(([^)]*)\)`)
+	comparisonRegexp  = regexp.MustCompile("(?i)([\\w.`\"]+)\\)?\\s*(?:=|<>|!=|<=|>=|<|>|\\bLIKE\\b)\\s*(?:LOWER\\()?(\\?|\\$\\d+)")
+)
+
+// redactArgs replaces the args bound to a sensitive column, the columns are found
+// from the INSERT columns list or the comparisons like "col = ?" of the query.
+func redactArgs(query string, args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return args
+	}
+	sensitive := map[int]bool{}
+	if m := insertRegexp.FindStringSubmatch(query); m != nil {
+		cols := strings.Split(m[1], ",")
+		vals := strings.Split(m[2], ",")
+		ordinal := 0
+		for i, v := range vals {
+			v = strings.TrimSpace(v)
+			if !placeholderRegexp.MatchString(v) {
+				continue
+			}
+			if i < len(cols) && SensitiveColumns[unquoteIdent(cols[i])] {
+				sensitive[argIndex(v, ordinal)] = true
+			}
+			ordinal++
+		}
+	}
+	for _, loc := range comparisonRegexp.FindAllStringSubmatchIndex(query, -1) {
+		col := unquoteIdent(query[loc[2]:loc[3]])
+		if i := strings.LastIndex(col, "."); i >= 0 {
+			col = col[i+1:]
+		}
+		if SensitiveColumns[col] {
+			ordinal := len(placeholderRegexp.FindAllStringIndex(query[:loc[4]], -1))
+			sensitive[argIndex(query[loc[4]:loc[5]], ordinal)] = true
+		}
+	}
+	if len(sensitive) == 0 {
+		return args
+	}
+	out := make([]interface{}, len(args))
+	copy(out, args)
+	for i := range sensitive {
+		if i >= 0 && i < len(out) {
+			out[i] = redacted
+		}
+	}
+	return out
+}
+
+// argIndex is the index of the arg of a placeholder: n-1 for $n, else the ordinal of the ?.
+func argIndex(placeholder string, ordinal int) int {
+	if strings.HasPrefix(placeholder, "$") {
+		n, _ := strconv.Atoi(placeholder[1:])
+		return n - 1
+	}
+	return ordinal
+}
+
+func unquoteIdent(s string) string {
+	return strings.Trim(strings.TrimSpace(s), "`\"")
+}
+
+// SlogObserver logs the queries with log/slog, the queries slower than SlowThreshold
+// are logged at the warn level, the failed ones at the error level and the others at debug.
+type SlogObserver struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration
+}
+
+// NewSlogObserver returns a SlogObserver, a nil logger is slog.Default().
+func NewSlogObserver(logger *slog.Logger, slowThreshold time.Duration) *SlogObserver {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogObserver{Logger: logger, SlowThreshold: slowThreshold}
+}
+
+// ObserveQuery implements QueryObserver.
+func (o *SlogObserver) ObserveQuery(ctx context.Context, e QueryEvent) {
+	attrs := []slog.Attr{
+		slog.String("sql", e.SQL),
+		slog.Any("args", e.Args),
+		slog.Duration("duration", e.Duration),
+	}
+	if e.RowsAffected >= 0 {
+		attrs = append(attrs, slog.Int64("rows_affected", e.RowsAffected))
+	}
+	switch {
+	case e.Err != nil:
+		o.Logger.LogAttrs(ctx, slog.LevelError, "query failed", append(attrs, slog.String("error", e.Err.Error()))...)
+	case o.SlowThreshold > 0 && e.Duration >= o.SlowThreshold:
+		o.Logger.LogAttrs(ctx, slog.LevelWarn, "slow query", attrs...)
+	default:
+		o.Logger.LogAttrs(ctx, slog.LevelDebug, "query", attrs...)
+	}
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page is a page of the records of a model, it's a simple keyset pagination on the ids.
+type Page[T Model] struct {
+	WhereString string
+	WhereParams []interface{}
+	// Order of the records, it must have an id Sort: the pages are keyed on the ids
+	Order      []Sort
+	FirstId    int64
+	LastId     int64
+	PageNum    int
+	PerPage    int
+	TotalPages int
+	TotalItems int64
+	// Context of the page queries, it's context.Background() if nil
+	Context  context.Context
+	orderStr string
+}
+
+func (_p *Page[T]) context() context.Context {
+	if _p.Context == nil {
+		return context.Background()
+	}
+	return _p.Context
+}
+
+func (_p *Page[T]) model() string {
+	var zero T
+	return zero.ModelName() + "Page"
+}
+
+// Current get the current page of the Page object for pagination.
+func (_p *Page[T]) Current() ([]T, error) {
+	return _p.load("current")
+}
+
+// Previous get the previous page of the Page object for pagination.
+func (_p *Page[T]) Previous() ([]T, error) {
+	if _p.PageNum == 0 {
+		return nil, fmt.Errorf("%w: This's the first page, no previous page yet", ErrNotFound)
+	}
+	records, err := _p.load("previous")
+	if err == nil {
+		_p.PageNum -= 1
+	}
+	return records, err
+}
+
+// Next get the next page of the Page object for pagination.
+func (_p *Page[T]) Next() ([]T, error) {
+	if _p.PageNum == _p.TotalPages-1 {
+		return nil, fmt.Errorf("%w: This's the last page, no next page yet", ErrNotFound)
+	}
+	records, err := _p.load("next")
+	if err == nil {
+		_p.PageNum += 1
+	}
+	return records, err
+}
+
+// After get the page of the records following the id in the id Sort of the Order, e.g. the LastId of the previous
+// page for a cursor based pagination, it's the first page for a zero id.
+func (_p *Page[T]) After(id int64) ([]T, error) {
+	if id == 0 {
+		_p.PageNum, _p.FirstId, _p.LastId = 0, 0, 0
+		return _p.load("current")
+	}
+	_p.LastId = id
+	return _p.load("next")
+}
+
+// Token encodes the id of a record as an opaque page token, e.g. the LastId for the next page,
+// the page following it is got by After(ParseToken(token)).
+func (_p *Page[T]) Token(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(_p.model() + ":" + strconv.FormatInt(id, 10)))
+}
+
+// ParseToken decodes a page token of Token to its id, it's 0 for a blank token.
+func (_p *Page[T]) ParseToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	prefix := _p.model() + ":"
+	if err != nil || !strings.HasPrefix(string(b), prefix) {
+		return 0, newValidationError(_p.model(), "token", "Invalid page token")
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(string(b), prefix), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, newValidationError(_p.model(), "token", "Invalid page token")
+	}
+	return id, nil
+}
+
+// GetPage is a helper function for the Page object to return a corresponding page due to
+// the parameter passed in, i.e. one of "previous, current or next".
+func (_p *Page[T]) GetPage(direction string) (ps []T, err error) {
+	switch direction {
+	case "previous":
+		ps, _ = _p.Previous()
+	case "next":
+		ps, _ = _p.Next()
+	case "current":
+		ps, _ = _p.Current()
+	default:
+		return nil, newValidationError(_p.model(), "direction", "None of previous, current or next")
+	}
+	return
+}
+
+func (_p *Page[T]) load(direction string) ([]T, error) {
+	if _, exist := _p.idSort(); !exist {
+		return nil, newValidationError(_p.model(), "order", "No id sort specified in Order")
+	}
+	err := _p.buildPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("Calculate page count error: %w", err)
+	}
+	if _p.orderStr == "" {
+		_p.buildOrder()
+	}
+	idStr, idParams := _p.buildIdRestrict(direction)
+	whereStr := fmt.Sprintf("%s %s %s%s", _p.WhereString, idStr, _p.orderStr, dialect.Limit(_p.PerPage, 0))
+	whereParams := []interface{}{}
+	whereParams = append(append(whereParams, _p.WhereParams...), idParams...)
+	r := NewRepository[T]()
+	records, err := r.Where(_p.context(), whereStr, whereParams...)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) != 0 {
+		_p.FirstId, _p.LastId = r.id(&records[0]), r.id(&records[len(records)-1])
+	}
+	return records, nil
+}
+
+// idSort returns the Sort of the id in the Order.
+func (_p *Page[T]) idSort() (Sort, bool) {
+	for _, s := range _p.Order {
+		if s.Column == "id" {
+			return s, true
+		}
+	}
+	return Sort{}, false
+}
+
+// buildOrder is for the Page object to build a SQL ORDER BY clause, by the order of the Sorts.
+func (_p *Page[T]) buildOrder() {
+	tempList := []string{}
+	for _, s := range _p.Order {
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		tempList = append(tempList, s.Column+" "+dir)
+	}
+	_p.orderStr = " ORDER BY " + strings.Join(tempList, ", ")
+}
+
+// buildIdRestrict is for the Page object to build a SQL clause for ID restriction,
+// implementing a simple keyset style pagination.
+func (_p *Page[T]) buildIdRestrict(direction string) (idStr string, idParams []interface{}) {
+	id, _ := _p.idSort()
+	switch direction {
+	case "previous":
+		if id.Desc {
+			idStr += "id > ? "
+			idParams = append(idParams, _p.FirstId)
+		} else {
+			idStr += "id < ? "
+			idParams = append(idParams, _p.FirstId)
+		}
+	case "current":
+		// trick to make Where function work
+		if _p.PageNum == 0 && _p.FirstId == 0 && _p.LastId == 0 {
+			idStr += "id > ? "
+			idParams = append(idParams, 0)
+		} else {
+			if id.Desc {
+				idStr += "id <= ? AND id >= ? "
+				idParams = append(idParams, _p.FirstId, _p.LastId)
+			} else {
+				idStr += "id >= ? AND id <= ? "
+				idParams = append(idParams, _p.FirstId, _p.LastId)
+			}
+		}
+	case "next":
+		if id.Desc {
+			idStr += "id < ? "
+			idParams = append(idParams, _p.LastId)
+		} else {
+			idStr += "id > ? "
+			idParams = append(idParams, _p.LastId)
+		}
+	}
+	if _p.WhereString != "" {
+		idStr = " AND " + idStr
+	}
+	return
+}
+
+// buildPageCount calculate the TotalItems/TotalPages for the Page object.
+func (_p *Page[T]) buildPageCount() error {
+	var zero T
+	defer observePageCount(zero.ModelName(), time.Now())
+	count, err := NewRepository[T]().CountWhere(_p.context(), _p.WhereString, _p.WhereParams...)
+	if err != nil {
+		return err
+	}
+	_p.TotalItems = count
+	if _p.PerPage == 0 {
+		_p.PerPage = 10
+	}
+	_p.TotalPages = int(math.Ceil(float64(_p.TotalItems) / float64(_p.PerPage)))
+	return nil
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter is a condition on a column, e.g. Filter{"created_at", "gte", "2024-01-01"},
+// its value is converted to the type of the column.
+type Filter struct {
+	Column string
+	Op     string
+	Value  string
+}
+
+// Sort is the order of the records on a column.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// FilterOps are the SQL operators of the Filter ops, the values of "in" are comma separated
+// and "like" is only allowed on the string columns.
+var FilterOps = map[string]string{
+	"eq":   "=",
+	"ne":   "<>",
+	"gt":   ">",
+	"gte":  ">=",
+	"lt":   "<",
+	"lte":  "<=",
+	"like": "LIKE",
+	"in":   "IN",
+}
+
+// filterTimeLayouts are the accepted layouts of the values on the time columns.
+var filterTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// WhereClause builds a parameterized where clause ANDing the filters and ordered by the sorts,
+// for Where and the Find...Where functions, e.g. FindPostsWhere(PostRepository.WhereClause(filters, sorts)).
+// An error is returned for the columns not of the table, the unknown ops and the invalid values.
+func (r *Repository[T]) WhereClause(filters []Filter, sorts []Sort) (string, []interface{}, error) {
+	conds := []string{}
+	args := []interface{}{}
+	for _, f := range filters {
+		kind, ok := r.kinds[f.Column]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown %s field %q", r.model, f.Column)
+		}
+		op, ok := FilterOps[f.Op]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown operator %q on the field %q", f.Op, f.Column)
+		}
+		switch f.Op {
+		case "in":
+			vals := strings.Split(f.Value, ",")
+			for _, v := range vals {
+				arg, err := filterValue(kind, f.Column, v)
+				if err != nil {
+					return "", nil, err
+				}
+				args = append(args, arg)
+			}
+			conds = append(conds, fmt.Sprintf("%s.%s IN (?%s)", r.table, f.Column, strings.Repeat(",?", len(vals)-1)))
+		case "like":
+			if kind != "string" {
+				return "", nil, fmt.Errorf("operator like isn't allowed on the field %q", f.Column)
+			}
+			args = append(args, f.Value)
+			conds = append(conds, fmt.Sprintf("%s.%s LIKE ?", r.table, f.Column))
+		default:
+			arg, err := filterValue(kind, f.Column, f.Value)
+			if err != nil {
+				return "", nil, err
+			}
+			args = append(args, arg)
+			conds = append(conds, fmt.Sprintf("%s.%s %s ?", r.table, f.Column, op))
+		}
+	}
+	orders := []string{}
+	for _, s := range sorts {
+		if _, ok := r.kinds[s.Column]; !ok {
+			return "", nil, fmt.Errorf("unknown %s sort field %q", r.model, s.Column)
+		}
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		orders = append(orders, fmt.Sprintf("%s.%s %s", r.table, s.Column, dir))
+	}
+	where := strings.Join(conds, " AND ")
+	if len(orders) > 0 {
+		if where == "" {
+			where = "1 = 1"
+		}
+		where += " ORDER BY " + strings.Join(orders, ", ")
+	}
+	return where, args, nil
+}
+
+// filterValue converts the value of a filter to the kind of its column.
+func filterValue(kind, column, value string) (interface{}, error) {
+	var v interface{}
+	var err error
+	switch kind {
+	case "integer":
+		v, err = strconv.ParseInt(value, 10, 64)
+	case "float":
+		v, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		v, err = strconv.ParseBool(value)
+	case "time":
+		for _, layout := range filterTimeLayouts {
+			var t time.Time
+			if t, err = time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+	case "string":
+		return value, nil
+	default:
+		return nil, fmt.Errorf("the field %q can't be filtered", column)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %q of the field %q", kind, value, column)
+	}
+	return v, nil
+}
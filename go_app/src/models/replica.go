@@ -0,0 +1,117 @@
+package models
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ReplicaHealthInterval is how often the replicas are pinged, an unhealthy replica
+// gets no reads until a ping succeeds again.
+var ReplicaHealthInterval = 5 * time.Second
+
+type replica struct {
+	db      *sqlx.DB
+	healthy int32
+}
+
+// replicas are the read replicas of DB, the finders read from them round-robin.
+// DB is always the primary, so the writes and the transactions begun on DB use it.
+var replicas struct {
+	sync.RWMutex
+	pool []*replica
+	next uint32
+	stop chan struct{}
+}
+
+type primaryKey struct{}
+
+// Primary returns a context making the ...Context finders read from the primary,
+// e.g. to read your own writes right after a Save.
+func Primary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// OpenReplicas connects the read replicas with the driver of DB and starts their health checks,
+// the replicas opened before are closed.
+func OpenReplicas(dsns ...string) error {
+	pool := []*replica{}
+	for _, dsn := range dsns {
+		db, err := connect(dialect.Name(), dsn)
+		if err != nil {
+			for _, r := range pool {
+				r.db.Close()
+			}
+			return err
+		}
+		pool = append(pool, &replica{db: db, healthy: 1})
+	}
+	CloseReplicas()
+	replicas.Lock()
+	replicas.pool = pool
+	replicas.stop = make(chan struct{})
+	go checkReplicas(pool, replicas.stop)
+	replicas.Unlock()
+	return nil
+}
+
+// CloseReplicas stops the health checks and closes the read replicas.
+func CloseReplicas() {
+	replicas.Lock()
+	defer replicas.Unlock()
+	if replicas.stop != nil {
+		close(replicas.stop)
+		replicas.stop = nil
+	}
+	for _, r := range replicas.pool {
+		purgeStmts(r.db)
+		r.db.Close()
+	}
+	replicas.pool = nil
+}
+
+func checkReplicas(pool []*replica, stop chan struct{}) {
+	ticker := time.NewTicker(ReplicaHealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		for _, r := range pool {
+			ctx, cancel := context.WithTimeout(context.Background(), ReplicaHealthInterval)
+			err := r.db.PingContext(ctx)
+			cancel()
+			healthy := int32(1)
+			if err != nil {
+				healthy = 0
+			}
+			if atomic.SwapInt32(&r.healthy, healthy) != healthy {
+				log.Printf("Replica health changed, healthy: %v, error: %v\n", healthy == 1, err)
+			}
+		}
+	}
+}
+
+// reader returns the database a finder reads from: the next healthy replica,
+// or the primary if ctx is marked by Primary or no replica is healthy.
+func reader(ctx context.Context) *sqlx.DB {
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return DB
+	}
+	replicas.RLock()
+	defer replicas.RUnlock()
+	n := len(replicas.pool)
+	for i := 0; i < n; i++ {
+		r := replicas.pool[int(atomic.AddUint32(&replicas.next, 1)%uint32(n))]
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			return r.db
+		}
+	}
+	return DB
+}
@@ -0,0 +1,85 @@
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+)
+
+// openReplica creates a SQLite database of the tables of modeltest.Schema, with a post 1 of the
+// title, and returns its DSN.
+func openReplica(t *testing.T, title string) string {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "replica.db")
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(modeltest.Schema); err != nil {
+		t.Fatalf("Create replica tables error: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO posts (id, title, content, created_at, updated_at) VALUES (1, ?, 'Some post content here, long enough', datetime('now'), datetime('now'))`, title); err != nil {
+		t.Fatalf("Insert replica post error: %v", err)
+	}
+	return dsn
+}
+
+// readTitle reads the title of the post 1, telling the database it's read from.
+func readTitle(t *testing.T, ctx context.Context) string {
+	t.Helper()
+	posts, err := m.FindPostsWhereContext(ctx, "id = ?", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("%d posts 1, want 1", len(posts))
+	}
+	return posts[0].Title.V
+}
+
+func TestReplicas(t *testing.T) {
+	modeltest.Open(t)
+	modeltest.CreatePost(t, modeltest.CreateUser(t, "primary@example.com"), "Primary post", "Some post content here, long enough")
+	if err := m.OpenReplicas(openReplica(t, "Replica one post"), openReplica(t, "Replica two post")); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	t.Run("round-robin", func(t *testing.T) {
+		seen := map[string]int{}
+		prev := ""
+		for i := 0; i < 4; i++ {
+			title := readTitle(t, ctx)
+			if title == prev {
+				t.Errorf("read %d from %q again", i, title)
+			}
+			seen[title]++
+			prev = title
+		}
+		if seen["Replica one post"] != 2 || seen["Replica two post"] != 2 {
+			t.Errorf("reads = %v, want 2 from each replica", seen)
+		}
+	})
+
+	t.Run("primary", func(t *testing.T) {
+		if title := readTitle(t, m.Primary(ctx)); title != "Primary post" {
+			t.Errorf("read from %q, want the primary", title)
+		}
+	})
+
+	t.Run("unhealthy", func(t *testing.T) {
+		m.SetReplicasHealthy(false)
+		defer m.SetReplicasHealthy(true)
+		for i := 0; i < 2; i++ {
+			if title := readTitle(t, ctx); title != "Primary post" {
+				t.Errorf("read %d from %q, want the primary", i, title)
+			}
+		}
+	})
+}
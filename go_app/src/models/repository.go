@@ -0,0 +1,453 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Model is the constraint of the model structs: a struct with db tagged fields, an int64 id
+// column and optionally the created_at/updated_at timestamps, naming its model and table.
+type Model interface {
+	ModelName() string
+	TableName() string
+}
+
+// Repository provides the CRUD functions of a model, its columns are read from the db tags of
+// the struct, the fields of the associations are skipped.
+type Repository[T Model] struct {
+	model         string
+	table         string
+	columns       []string
+	kinds         map[string]string // column => goKind of its field
+	selectColumns string
+	idIndex       int
+	createdIndex  int
+	updatedIndex  int
+}
+
+var repositories sync.Map // reflect.Type => *Repository[T]
+
+// NewRepository returns the Repository of a model, it's built and the model is registered
+// for the validation on the first call only.
+func NewRepository[T Model]() *Repository[T] {
+	var zero T
+	t := reflect.TypeOf(zero)
+	if r, ok := repositories.Load(t); ok {
+		return r.(*Repository[T])
+	}
+	r := &Repository[T]{model: zero.ModelName(), table: zero.TableName(), idIndex: -1, createdIndex: -1, updatedIndex: -1,
+		kinds: map[string]string{}}
+	selects := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		col := f.Tag.Get("db")
+		kind, _ := goKind(f.Type)
+		if col == "" || col == "-" || kind == "" {
+			continue
+		}
+		switch col {
+		case "id":
+			r.idIndex = i
+		case "created_at":
+			r.createdIndex = i
+		case "updated_at":
+			r.updatedIndex = i
+		}
+		r.columns = append(r.columns, col)
+		r.kinds[col] = kind
+		selects = append(selects, r.table+"."+col)
+	}
+	if r.idIndex < 0 {
+		panic(fmt.Sprintf("models: %s has no id field", r.model))
+	}
+	r.selectColumns = strings.Join(selects, ", ")
+	actual, loaded := repositories.LoadOrStore(t, r)
+	if !loaded {
+		registerModel(r.model, r.table, zero)
+	}
+	return actual.(*Repository[T])
+}
+
+// Columns returns the columns of the table.
+func (r *Repository[T]) Columns() []string {
+	return r.columns
+}
+
+func (r *Repository[T]) id(v *T) int64 {
+	return reflect.ValueOf(v).Elem().Field(r.idIndex).Int()
+}
+
+func (r *Repository[T]) setTimestamps(v *T, t time.Time, created bool) {
+	rv := reflect.ValueOf(v).Elem()
+	if created && r.createdIndex >= 0 {
+		rv.Field(r.createdIndex).Set(reflect.ValueOf(t))
+	}
+	if r.updatedIndex >= 0 {
+		rv.Field(r.updatedIndex).Set(reflect.ValueOf(t))
+	}
+}
+
+func (r *Repository[T]) selectSQL() string {
+	return "SELECT " + r.selectColumns + " FROM " + r.table
+}
+
+// get reads a single record, it reads from a replica unless ctx is marked by Primary.
+func (r *Repository[T]) get(ctx context.Context, sql string, args ...interface{}) (*T, error) {
+	v := new(T)
+	err := reader(ctx).GetContext(ctx, v, DB.Rebind(sql), args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return v, nil
+}
+
+// query reads records with a cached prepared statement, from a replica unless ctx is marked by Primary.
+func (r *Repository[T]) query(ctx context.Context, dest interface{}, sql string, args ...interface{}) error {
+	stmt, err := prepareStmt(ctx, reader(ctx), DB.Rebind(sql))
+	if err != nil {
+		return translateError(err)
+	}
+	defer stmt.release()
+	err = stmt.SelectContext(ctx, dest, args...)
+	if err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+// exec executes a write on DB with a cached prepared statement and returns the count of rows affected.
+func (r *Repository[T]) exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	stmt, err := prepareStmt(ctx, DB, DB.Rebind(sql))
+	if err != nil {
+		return 0, translateError(err)
+	}
+	defer stmt.release()
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	cnt, err := result.RowsAffected()
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return cnt, nil
+}
+
+func where(sql, where string) string {
+	if len(where) > 0 {
+		return sql + " WHERE " + where
+	}
+	return sql
+}
+
+func idsArgs(ids []int64) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return "?" + strings.Repeat(",?", len(ids)-1), args
+}
+
+// Find finds a single record by an ID.
+func (r *Repository[T]) Find(ctx context.Context, id int64) (*T, error) {
+	if id == 0 {
+		return nil, newValidationError(r.model, "id", "Invalid ID: it can't be zero")
+	}
+	return r.get(ctx, fmt.Sprintf("%s WHERE %s.id = ? LIMIT 1", r.selectSQL(), r.table), id)
+}
+
+// First finds the first record by ID ASC order.
+func (r *Repository[T]) First(ctx context.Context) (*T, error) {
+	return r.get(ctx, fmt.Sprintf("%s ORDER BY %s.id ASC LIMIT 1", r.selectSQL(), r.table))
+}
+
+// FirstN finds the first N records by ID ASC order.
+func (r *Repository[T]) FirstN(ctx context.Context, n uint32) ([]T, error) {
+	return r.FindBySql(ctx, fmt.Sprintf("%s ORDER BY %s.id ASC%s", r.selectSQL(), r.table, dialect.Limit(int(n), 0)))
+}
+
+// Last finds the last record by ID DESC order.
+func (r *Repository[T]) Last(ctx context.Context) (*T, error) {
+	return r.get(ctx, fmt.Sprintf("%s ORDER BY %s.id DESC LIMIT 1", r.selectSQL(), r.table))
+}
+
+// LastN finds the last N records by ID DESC order.
+func (r *Repository[T]) LastN(ctx context.Context, n uint32) ([]T, error) {
+	return r.FindBySql(ctx, fmt.Sprintf("%s ORDER BY %s.id DESC%s", r.selectSQL(), r.table, dialect.Limit(int(n), 0)))
+}
+
+// FindMany finds one or more records by the given ID(s).
+func (r *Repository[T]) FindMany(ctx context.Context, ids ...int64) ([]T, error) {
+	if len(ids) == 0 {
+		return nil, newValidationError(r.model, "ids", "At least one or more ids needed")
+	}
+	holders, args := idsArgs(ids)
+	return r.FindBySql(ctx, fmt.Sprintf("%s WHERE %s.id IN (%s)", r.selectSQL(), r.table, holders), args...)
+}
+
+// FindBy finds a single record by a field name and a value.
+func (r *Repository[T]) FindBy(ctx context.Context, field string, val interface{}) (*T, error) {
+	return r.get(ctx, fmt.Sprintf("%s WHERE %s = ? LIMIT 1", r.selectSQL(), field), val)
+}
+
+// FindAllBy finds all the records by a field name and a value.
+func (r *Repository[T]) FindAllBy(ctx context.Context, field string, val interface{}) ([]T, error) {
+	return r.Where(ctx, field+" = ?", val)
+}
+
+// All gets all the records.
+func (r *Repository[T]) All(ctx context.Context) ([]T, error) {
+	return r.Where(ctx, "")
+}
+
+// Count gets the count of all the records.
+func (r *Repository[T]) Count(ctx context.Context) (int64, error) {
+	return r.CountWhere(ctx, "")
+}
+
+// CountWhere gets the count of the records with a where clause.
+func (r *Repository[T]) CountWhere(ctx context.Context, whereSQL string, args ...interface{}) (c int64, err error) {
+	stmt, err := prepareStmt(ctx, reader(ctx), DB.Rebind(where("SELECT count(*) FROM "+r.table, whereSQL)))
+	if err != nil {
+		return 0, translateError(err)
+	}
+	defer stmt.release()
+	err = stmt.GetContext(ctx, &c, args...)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return c, nil
+}
+
+// Ids gets the IDs of the records with a where clause, all of them for a blank where.
+func (r *Repository[T]) Ids(ctx context.Context, whereSQL string, args ...interface{}) ([]int64, error) {
+	return r.IntCol(ctx, "id", whereSQL, args...)
+}
+
+// IntCol gets an int64 typed column of the records with a where clause.
+func (r *Repository[T]) IntCol(ctx context.Context, col, whereSQL string, args ...interface{}) (recs []int64, err error) {
+	err = r.query(ctx, &recs, where("SELECT "+col+" FROM "+r.table, whereSQL), args...)
+	return recs, err
+}
+
+// StrCol gets a string typed column of the records with a where clause.
+func (r *Repository[T]) StrCol(ctx context.Context, col, whereSQL string, args ...interface{}) (recs []string, err error) {
+	err = r.query(ctx, &recs, where("SELECT "+col+" FROM "+r.table, whereSQL), args...)
+	return recs, err
+}
+
+// Where finds the records with a partial SQL clause that usually follows WHERE, with placeholders.
+func (r *Repository[T]) Where(ctx context.Context, whereSQL string, args ...interface{}) ([]T, error) {
+	return r.FindBySql(ctx, where(r.selectSQL(), whereSQL), args...)
+}
+
+// FindOneBySql finds a single record with a complete SQL query with placeholders.
+func (r *Repository[T]) FindOneBySql(ctx context.Context, sql string, args ...interface{}) (*T, error) {
+	stmt, err := prepareStmt(ctx, reader(ctx), DB.Rebind(sql))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer stmt.release()
+	v := new(T)
+	err = stmt.GetContext(ctx, v, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return v, nil
+}
+
+// FindBySql finds the records with a complete SQL query with placeholders.
+func (r *Repository[T]) FindBySql(ctx context.Context, sql string, args ...interface{}) ([]T, error) {
+	records := []T{}
+	if err := r.query(ctx, &records, sql, args...); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// CreateMap validates the attributes and creates a record, the timestamps are set if missing.
+// The keys must be columns of the table, am itself isn't modified.
+func (r *Repository[T]) CreateMap(ctx context.Context, am map[string]interface{}) (int64, error) {
+	if len(am) == 0 {
+		return 0, newValidationError(r.model, "attributes", "Zero key in the attributes map!")
+	}
+	if err := checkColumns(r.model, r.columns, am); err != nil {
+		return 0, err
+	}
+	if err := validateAttrs(r.model, 0, am, false); err != nil {
+		return 0, err
+	}
+	// the timestamps aren't written to the map of the caller
+	am = maps.Clone(am)
+	t := now()
+	for _, v := range []string{"created_at", "updated_at"} {
+		if am[v] == nil && r.hasColumn(v) {
+			am[v] = t
+		}
+	}
+	keys := allKeys(am)
+	sql := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, r.table, strings.Join(quoteColumns(keys), ","), ":"+strings.Join(keys, ",:"))
+	lastId, err := insertReturningId(sql, am)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return lastId, nil
+}
+
+// Create validates and creates a record, its timestamps and id are set.
+func (r *Repository[T]) Create(ctx context.Context, v *T) (int64, error) {
+	if err := validateStruct(r.model, v); err != nil {
+		return 0, err
+	}
+	r.setTimestamps(v, now(), true)
+	cols := r.columns[:0:0]
+	for _, c := range r.columns {
+		if c != "id" {
+			cols = append(cols, c)
+		}
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, r.table, strings.Join(quoteColumns(cols), ","), ":"+strings.Join(cols, ",:"))
+	lastId, err := insertReturningId(sql, v)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	reflect.ValueOf(v).Elem().Field(r.idIndex).SetInt(lastId)
+	return lastId, nil
+}
+
+// Save validates and saves a record: it's created without an id, else upserted on the id.
+func (r *Repository[T]) Save(ctx context.Context, v *T) error {
+	if r.id(v) == 0 {
+		// validated by Create
+		_, err := r.Create(ctx, v)
+		return err
+	}
+	if err := validateStruct(r.model, v); err != nil {
+		return err
+	}
+	t := now()
+	if r.createdIndex >= 0 {
+		created := reflect.ValueOf(v).Elem().Field(r.createdIndex)
+		r.setTimestamps(v, t, created.Interface().(time.Time).IsZero())
+	} else {
+		r.setTimestamps(v, t, false)
+	}
+	updates := []string{}
+	for _, c := range r.columns {
+		if c != "id" && c != "created_at" {
+			updates = append(updates, c)
+		}
+	}
+	_, err := DB.NamedExecContext(ctx, dialect.Upsert(r.table, r.columns, updates, "id"), v)
+	return translateError(err)
+}
+
+// Update validates the attributes and updates a record by its id.
+func (r *Repository[T]) Update(ctx context.Context, id int64, am map[string]interface{}) error {
+	if len(am) == 0 {
+		return newValidationError(r.model, "attributes", "Zero key in the attributes map!")
+	}
+	if err := checkColumns(r.model, r.columns, am); err != nil {
+		return err
+	}
+	if err := validateAttrs(r.model, id, am, true); err != nil {
+		return err
+	}
+	return r.UpdateColumns(ctx, id, am)
+}
+
+// UpdateColumns updates the columns of a record without validation, as update_columns in Rails.
+// It returns ErrNotFound if there's no record of the id, am itself isn't modified.
+func (r *Repository[T]) UpdateColumns(ctx context.Context, id int64, am map[string]interface{}) error {
+	if len(am) == 0 {
+		return newValidationError(r.model, "attributes", "Zero key in the attributes map!")
+	}
+	if err := checkColumns(r.model, r.columns, am); err != nil {
+		return err
+	}
+	// the timestamps aren't written to the map of the caller
+	am = maps.Clone(am)
+	if r.hasColumn("updated_at") {
+		am["updated_at"] = now()
+	}
+	keys := allKeys(am)
+	setKeysArr := []string{}
+	for _, v := range keys {
+		setKeysArr = append(setKeysArr, fmt.Sprintf(" %s = :%s", dialect.Quote(v), v))
+	}
+	sqlStr := fmt.Sprintf(`UPDATE %s SET %s WHERE id = %v`, r.table, strings.Join(setKeysArr, ", "), id)
+	result, err := DB.NamedExecContext(ctx, sqlStr, am)
+	if err != nil {
+		return translateError(err)
+	}
+	if cnt, err := result.RowsAffected(); err != nil {
+		return translateError(err)
+	} else if cnt == 0 {
+		// MySQL doesn't count the rows matched but left unchanged
+		return r.exists(ctx, id)
+	}
+	return nil
+}
+
+// UpdateBySql updates records with a complete SQL clause using the '?' binding syntax.
+func (r *Repository[T]) UpdateBySql(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	if sql == "" {
+		return 0, newValidationError(r.model, "sql", "A blank SQL clause")
+	}
+	return r.exec(ctx, sql, args...)
+}
+
+// Destroy deletes a record by its id, it returns ErrNotFound if there's no record of the id.
+func (r *Repository[T]) Destroy(ctx context.Context, id int64) error {
+	cnt, err := r.exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, r.table), id)
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DestroyMany deletes the records of the ids and returns the count deleted.
+func (r *Repository[T]) DestroyMany(ctx context.Context, ids ...int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, newValidationError(r.model, "ids", "At least one or more ids needed")
+	}
+	holders, args := idsArgs(ids)
+	return r.exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id IN (%s)`, r.table, holders), args...)
+}
+
+// DestroyWhere deletes the records by a where clause, no association dependent action is run.
+func (r *Repository[T]) DestroyWhere(ctx context.Context, whereSQL string, args ...interface{}) (int64, error) {
+	if len(whereSQL) == 0 {
+		return 0, newValidationError(r.model, "where", "No WHERE conditions provided")
+	}
+	return r.exec(ctx, `DELETE FROM `+r.table+` WHERE `+whereSQL, args...)
+}
+
+// exists returns ErrNotFound if there's no record of the id on the primary.
+func (r *Repository[T]) exists(ctx context.Context, id int64) error {
+	cnt, err := r.CountWhere(Primary(ctx), "id = ?", id)
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository[T]) hasColumn(col string) bool {
+	for _, c := range r.columns {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
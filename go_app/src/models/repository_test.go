@@ -0,0 +1,256 @@
+package models_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+)
+
+func TestRepositoryCRUD(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "reader@example.com")
+	id := modeltest.CreatePost(t, userId, "A post title", "Some post content here, long enough")
+
+	post, err := m.FindPost(id)
+	if err != nil {
+		t.Fatalf("FindPost: %v", err)
+	}
+	if post.Title.V != "A post title" || post.UserId.V != userId || post.CreatedAt.IsZero() {
+		t.Errorf("FindPost = %+v", post)
+	}
+
+	if err := m.UpdatePost(id, map[string]interface{}{"title": "Another title"}); err != nil {
+		t.Fatalf("UpdatePost: %v", err)
+	}
+	if post, _ = m.FindPost(id); post.Title.V != "Another title" {
+		t.Errorf("title after UpdatePost = %q", post.Title.V)
+	}
+
+	modeltest.CreatePost(t, userId, "A second title", "Some more post content here")
+	if n, err := m.PostCount(); err != nil || n != 2 {
+		t.Errorf("PostCount = %d, %v, want 2", n, err)
+	}
+	posts, err := m.FindPostsWhere("title LIKE ?", "A second%")
+	if err != nil || len(posts) != 1 {
+		t.Errorf("FindPostsWhere = %d posts, %v, want 1", len(posts), err)
+	}
+	if posts, err = m.LastPosts(1); err != nil || len(posts) != 1 || posts[0].Id == id {
+		t.Errorf("LastPosts(1) = %+v, %v", posts, err)
+	}
+
+	if err := m.DestroyPost(id); err != nil {
+		t.Fatalf("DestroyPost: %v", err)
+	}
+	if _, err := m.FindPost(id); !errors.Is(err, m.ErrNotFound) {
+		t.Errorf("FindPost after DestroyPost error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRepositorySave(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "writer@example.com")
+	post := &m.Post{Title: m.NewNull("A saved title"), Content: m.NewNull("Some saved post content here"), UserId: m.NewNull(userId)}
+	if err := post.Save(); err != nil {
+		t.Fatalf("Save a new post: %v", err)
+	}
+	if post.Id == 0 || post.CreatedAt.IsZero() {
+		t.Fatalf("Save didn't set the id and the timestamps: %+v", post)
+	}
+	post.Title = m.NewNull("A resaved title")
+	if err := post.Save(); err != nil {
+		t.Fatalf("Save an existing post: %v", err)
+	}
+	saved, err := m.FindPost(post.Id)
+	if err != nil || saved.Title.V != "A resaved title" {
+		t.Errorf("FindPost after Save = %+v, %v", saved, err)
+	}
+	if n, _ := m.PostCount(); n != 1 {
+		t.Errorf("PostCount = %d, want 1", n)
+	}
+}
+
+func TestRepositoryMissingRecord(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "missing@example.com")
+	if err := m.UpdatePost(404, map[string]interface{}{"title": "A missing title"}); !errors.Is(err, m.ErrNotFound) {
+		t.Errorf("UpdatePost of a missing id error = %v, want ErrNotFound", err)
+	}
+	if err := m.PostRepository.UpdateColumns(context.Background(), 404, map[string]interface{}{"title": "x"}); !errors.Is(err, m.ErrNotFound) {
+		t.Errorf("UpdateColumns of a missing id error = %v, want ErrNotFound", err)
+	}
+	if err := m.DestroyPost(404); !errors.Is(err, m.ErrNotFound) {
+		t.Errorf("DestroyPost of a missing id error = %v, want ErrNotFound", err)
+	}
+	// an update leaving the columns unchanged still finds the record
+	if err := m.UserRepository.UpdateColumns(context.Background(), userId, map[string]interface{}{"email": "missing@example.com"}); err != nil {
+		t.Errorf("UpdateColumns without a change error = %v", err)
+	}
+}
+
+func TestRepositoryAttributesNotModified(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "attrs@example.com")
+	am := map[string]interface{}{"title": "A post title", "content": "Some post content here, long enough", "user_id": userId}
+	id, err := m.CreatePost(am)
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := map[string]interface{}{"title": "Another title"}
+	if err := m.UpdatePost(id, update); err != nil {
+		t.Fatal(err)
+	}
+	if len(am) != 3 || len(update) != 1 {
+		t.Errorf("the attributes maps were modified: %v, %v", am, update)
+	}
+}
+
+// TestRepositorySaveValidatesOnce counts the queries of the Uniqueness validator of the user email.
+func TestRepositorySaveValidatesOnce(t *testing.T) {
+	modeltest.Open(t)
+	var counting atomic.Bool
+	var uniqueness atomic.Int64
+	m.AddQueryObserver(m.QueryObserverFunc(func(ctx context.Context, e m.QueryEvent) {
+		if counting.Load() && strings.Contains(e.SQL, "LOWER(email)") {
+			uniqueness.Add(1)
+		}
+	}))
+	counting.Store(true)
+	defer counting.Store(false)
+	user := &m.User{Email: "once@example.com"}
+	if err := user.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if n := uniqueness.Load(); n != 1 {
+		t.Errorf("the email uniqueness was checked %d times, want 1", n)
+	}
+}
+
+func TestRepositoryValidation(t *testing.T) {
+	modeltest.Open(t)
+	_, err := m.CreatePost(map[string]interface{}{"title": "short", "content": "Some post content here, long enough"})
+	var ve *m.ValidationError
+	if !errors.As(err, &ve) || !errors.Is(err, m.ErrValidation) {
+		t.Fatalf("CreatePost error = %v, want a ValidationError", err)
+	}
+	fields := map[string]bool{}
+	for _, fe := range ve.Errors {
+		fields[fe.Field] = true
+	}
+	if !fields["title"] || !fields["user"] {
+		t.Errorf("invalid fields = %v, want title and user", ve.Errors)
+	}
+}
+
+// TestRepositoryContentLength checks the content is validated at the size of its TEXT column.
+func TestRepositoryContentLength(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "long@example.com")
+	_, err := m.CreatePost(map[string]interface{}{"title": "A long post", "content": strings.Repeat("a", 65536), "user_id": userId})
+	var ve *m.ValidationError
+	if !errors.As(err, &ve) || len(ve.Errors) != 1 || ve.Errors[0].Field != "content" {
+		t.Errorf("CreatePost of a content over 65535 error = %v, want a content error", err)
+	}
+	modeltest.CreatePost(t, userId, "A long post", strings.Repeat("a", 65535))
+}
+
+func TestTranslateError(t *testing.T) {
+	modeltest.Open(t)
+	modeltest.CreateUser(t, "taken@example.com")
+	id := modeltest.CreateUser(t, "other@example.com")
+
+	// the unique index is hit without the Uniqueness validator
+	err := m.UserRepository.UpdateColumns(t.Context(), id, map[string]interface{}{"email": "taken@example.com"})
+	if !errors.Is(err, m.ErrDuplicate) {
+		t.Errorf("UpdateColumns error = %v, want ErrDuplicate", err)
+	}
+
+	_, err = m.DB.Exec(`INSERT INTO users (email, created_at, updated_at) VALUES ('taken@example.com', '', '')`)
+	if err == nil {
+		t.Fatal("inserting a duplicate email didn't fail")
+	}
+	wrapped := fmt.Errorf("insert user: %w", err)
+	if got := m.CurrentDialect().TranslateError(wrapped); got != m.ErrDuplicate {
+		t.Errorf("TranslateError of a wrapped error = %v, want ErrDuplicate", got)
+	}
+	if got := m.CurrentDialect().TranslateError(errors.New("UNIQUE constraint failed")); got != nil {
+		t.Errorf("TranslateError of a non driver error = %v, want nil", got)
+	}
+}
+
+func TestDialectBool(t *testing.T) {
+	modeltest.Open(t)
+	if _, err := m.DB.Exec(`CREATE TABLE flags (id INTEGER PRIMARY KEY, enabled boolean)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.DB.Exec(`INSERT INTO flags (id, enabled) VALUES (1, ?), (2, ?), (3, ?)`, true, false, m.NewNull(true)); err != nil {
+		t.Fatal(err)
+	}
+	// stored as the sqlite3 adapter of Rails does
+	var stored []string
+	if err := m.DB.Select(&stored, `SELECT enabled FROM flags ORDER BY id`); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(stored) != "[t f t]" {
+		t.Errorf("stored booleans = %v, want [t f t]", stored)
+	}
+	var enabled []bool
+	if err := m.DB.Select(&enabled, `SELECT enabled FROM flags WHERE enabled = ? ORDER BY id`, true); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(enabled) != "[true true]" {
+		t.Errorf("scanned booleans = %v, want [true true]", enabled)
+	}
+}
+
+// TestTimestampsJSON checks the zero timestamps are left out of the JSON and the set ones kept.
+func TestTimestampsJSON(t *testing.T) {
+	data, err := json.Marshal(m.Post{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"created_at"`) || strings.Contains(string(data), `"updated_at"`) {
+		t.Errorf("JSON of a new post = %s, want no timestamps", data)
+	}
+
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "json@example.com")
+	post, err := m.FindPost(modeltest.CreatePost(t, userId, "A post title", "Some post content here, long enough"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ = json.Marshal(post); !strings.Contains(string(data), `"created_at"`) {
+		t.Errorf("JSON of a saved post = %s, want its created_at", data)
+	}
+}
+
+// TestPageOrder checks the page is ordered by the Sorts in their order.
+func TestPageOrder(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "order@example.com")
+	for _, title := range []string{"Post title B", "Post title A", "Post title B", "Post title A"} {
+		modeltest.CreatePost(t, userId, title, "Some post content here, long enough")
+	}
+	page := &m.PostPage{Order: []m.Sort{{Column: "title"}, {Column: "id", Desc: true}}, PerPage: 10}
+	posts, err := page.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, p := range posts {
+		got = append(got, fmt.Sprintf("%s %d", p.Title.V, p.Id))
+	}
+	if want := "[Post title A 4 Post title A 2 Post title B 3 Post title B 1]"; fmt.Sprint(got) != want {
+		t.Errorf("posts = %v, want %s", got, want)
+	}
+
+	if _, err := (&m.PostPage{Order: []m.Sort{{Column: "title"}}}).Current(); err == nil {
+		t.Error("Current() of a page without an id Sort succeeded, want an error")
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+)
+
+// Column is the metadata of a table column read from information_schema.
+type Column struct {
+	Name          string         `db:"name"`
+	DataType      string         `db:"data_type"`
+	ColumnType    string         `db:"column_type"`
+	Nullable      bool           `db:"nullable"`
+	Default       sql.NullString `db:"default_value"`
+	CharMaxLength sql.NullInt64  `db:"char_max_length"`
+	ByteMaxLength sql.NullInt64  `db:"byte_max_length"`
+	Extra         string         `db:"extra"`
+}
+
+// TableColumns introspects the columns of a table in the current database.
+func TableColumns(table string) ([]Column, error) {
+	cols, err := dialect.Columns(table)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return cols, nil
+}
+
+// columnValidators are loaded once per model from the table metadata, by loadColumnValidators.
+var columnValidators = struct {
+	sync.Mutex
+	byModel map[string][]fieldValidator
+}{byModel: map[string][]fieldValidator{}}
+
+// loadColumnValidators returns the validators generated from the columns of the model table,
+// the table is introspected on the first call only.
+func loadColumnValidators(model string) ([]fieldValidator, error) {
+	columnValidators.Lock()
+	defer columnValidators.Unlock()
+	if fvs, ok := columnValidators.byModel[model]; ok {
+		return fvs, nil
+	}
+	cols, err := TableColumns(modelTables[model])
+	if err != nil {
+		return nil, err
+	}
+	fvs := []fieldValidator{}
+	for _, c := range cols {
+		for _, v := range c.validators() {
+			fvs = append(fvs, fieldValidator{field: c.Name, validator: v})
+		}
+	}
+	columnValidators.byModel[model] = fvs
+	return fvs, nil
+}
+
+// validators builds the validators enforcing the column limits before they're hit in the DB:
+// NOT NULL without a default value, the character length of (VAR)CHAR and the byte length of TEXT/BLOB.
+// The primary key and the timestamps are filled in by the model functions, so they're skipped.
+func (c Column) validators() (vs []Validator) {
+	if c.Name == "id" || c.Name == "created_at" || c.Name == "updated_at" || strings.Contains(c.Extra, "auto_increment") {
+		return nil
+	}
+	if !c.Nullable && !c.Default.Valid {
+		vs = append(vs, NotNull())
+	}
+	switch {
+	case (c.DataType == "varchar" || c.DataType == "char") && c.CharMaxLength.Valid:
+		vs = append(vs, Length(0, int(c.CharMaxLength.Int64)))
+	case strings.HasSuffix(c.DataType, "text") || strings.HasSuffix(c.DataType, "blob"):
+		if c.ByteMaxLength.Valid {
+			vs = append(vs, MaxBytes(int(c.ByteMaxLength.Int64)))
+		} else if c.CharMaxLength.Valid {
+			vs = append(vs, MaxBytes(int(c.CharMaxLength.Int64)))
+		}
+	}
+	return vs
+}
+
+// NotNull validates a field isn't nil when it's written, or missing from a full write.
+func NotNull() Validator {
+	return ValidatorFunc(func(v *Validation, field string) {
+		if v.Skip(field) {
+			return
+		}
+		if val, _ := v.Value(field); val == nil {
+			v.AddError(field, "blank", 0)
+		}
+	})
+}
+
+// MaxBytes validates the length in bytes of a string field, as the limit of a TEXT column.
+func MaxBytes(max int) Validator {
+	return ValidatorFunc(func(v *Validation, field string) {
+		s, ok := stringValue(v, field)
+		if ok && len(s) > max {
+			v.AddError(field, "too_long_bytes", max)
+		}
+	})
+}
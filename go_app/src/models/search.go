@@ -0,0 +1,306 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// PostHit is a post found by SearchPosts with its relevance score and the snippets of its
+// title and content, the matched terms are highlighted by <mark> tags in the HTML escaped text.
+type PostHit struct {
+	Post
+	Score    float64           `json:"score" db:"score"`
+	Snippets map[string]string `json:"snippets" db:"-"`
+	// Rank is the Score rounded by ScoreRank, the hits are ordered and paginated on it
+	Rank int64 `json:"-" db:"score_rank"`
+}
+
+// SearchCursor is the position of a hit in the results ordered by rank DESC, id DESC.
+type SearchCursor struct {
+	Rank int64
+	Id   int64
+}
+
+// searchRankScale is the precision of the ranks of the scores.
+const searchRankScale = 1000000
+
+// ScoreRank rounds a score to an integer rank, the hits are paginated on the ranks so the
+// cursor doesn't depend on the equality of float scores computed again by the next query.
+func ScoreRank(score float64) int64 {
+	return int64(math.Round(score * searchRankScale))
+}
+
+// SearchBackend runs the full-text queries of SearchPosts.
+type SearchBackend interface {
+	// Search returns up to limit posts matching any term of the query with their score and its
+	// rank, ordered by rank DESC, id DESC and positioned after the cursor if it's not nil.
+	Search(ctx context.Context, query string, after *SearchCursor, limit int) ([]PostHit, error)
+}
+
+var searchBackend struct {
+	sync.RWMutex
+	backend SearchBackend
+}
+
+// SetSearchBackend sets the backend of SearchPosts, it's also added as a query observer if it
+// implements QueryObserver, e.g. the MemorySearch to know the posts were changed.
+// By default it's the FULLTEXT index on MySQL, the TSVectorSearch on PostgreSQL and a MemorySearch on SQLite.
+func SetSearchBackend(b SearchBackend) {
+	searchBackend.Lock()
+	searchBackend.backend = b
+	searchBackend.Unlock()
+	if o, ok := b.(QueryObserver); ok {
+		AddQueryObserver(o)
+	}
+}
+
+func currentSearchBackend() SearchBackend {
+	searchBackend.RLock()
+	b := searchBackend.backend
+	searchBackend.RUnlock()
+	if b != nil {
+		return b
+	}
+	switch dialect.Name() {
+	case "mysql":
+		b = FulltextSearch{}
+	case "pgx":
+		b = TSVectorSearch{}
+	default:
+		b = NewMemorySearch()
+	}
+	searchBackend.Lock()
+	defer searchBackend.Unlock()
+	if searchBackend.backend != nil {
+		return searchBackend.backend
+	}
+	searchBackend.backend = b
+	if o, ok := b.(QueryObserver); ok {
+		AddQueryObserver(o)
+	}
+	return b
+}
+
+// SearchPage is a page of the search results for the keyset pagination of SearchPosts.
+type SearchPage struct {
+	PerPage int
+	// Cursor is the position of the last hit of the previous page, blank for the first page
+	Cursor string
+	// NextCursor is set by SearchPosts, it's blank on the last page
+	NextCursor string
+	// Context of the search queries, it's context.Background() if nil
+	Context context.Context
+}
+
+// MaxSearchPerPage is the maximum PerPage of a SearchPage.
+const MaxSearchPerPage = 100
+
+// snippetLength is the maximum length in bytes of the text of a snippet, the ellipses and marks aside.
+const snippetLength = 160
+
+// SearchPosts finds the posts whose title or content match the query, ranked by relevance.
+// The page is the first one if nil, else its NextCursor is set to get the next page with.
+func SearchPosts(query string, page *SearchPage) ([]PostHit, error) {
+	if page == nil {
+		page = &SearchPage{}
+	}
+	ctx := page.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(searchTerms(query)) == 0 {
+		return nil, newValidationError("SearchPage", "query", "No search term in the query")
+	}
+	if page.PerPage <= 0 {
+		page.PerPage = 10
+	}
+	if page.PerPage > MaxSearchPerPage {
+		page.PerPage = MaxSearchPerPage
+	}
+	var after *SearchCursor
+	if page.Cursor != "" {
+		c, err := decodeSearchCursor(page.Cursor)
+		if err != nil {
+			return nil, newValidationError("SearchPage", "cursor", "Invalid cursor")
+		}
+		after = &c
+	}
+	// one more hit tells if there's a next page
+	hits, err := currentSearchBackend().Search(ctx, query, after, page.PerPage+1)
+	if err != nil {
+		return nil, err
+	}
+	page.NextCursor = ""
+	if len(hits) > page.PerPage {
+		hits = hits[:page.PerPage]
+		last := hits[len(hits)-1]
+		page.NextCursor = encodeSearchCursor(SearchCursor{Rank: last.Rank, Id: last.Id})
+	}
+	terms := searchTerms(query)
+	for i := range hits {
+		hits[i].Snippets = map[string]string{
+			"title":   highlight(hits[i].Title.V, terms, snippetLength),
+			"content": highlight(hits[i].Content.V, terms, snippetLength),
+		}
+	}
+	return hits, nil
+}
+
+func encodeSearchCursor(c SearchCursor) string {
+	s := strconv.FormatInt(c.Rank, 10) + "," + strconv.FormatInt(c.Id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func decodeSearchCursor(s string) (c SearchCursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	parts := strings.SplitN(string(b), ",", 2)
+	if len(parts) != 2 {
+		return c, fmt.Errorf("invalid search cursor %q", s)
+	}
+	if c.Rank, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return c, err
+	}
+	c.Id, err = strconv.ParseInt(parts[1], 10, 64)
+	return c, err
+}
+
+// FulltextSearch is the SearchBackend of MySQL, it queries the FULLTEXT index on the title and
+// content of the posts in natural language mode.
+type FulltextSearch struct{}
+
+// Search implements SearchBackend.
+func (FulltextSearch) Search(ctx context.Context, query string, after *SearchCursor, limit int) ([]PostHit, error) {
+	const match = "MATCH (posts.title, posts.content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	sql := fmt.Sprintf("SELECT %s, %s AS score, CAST(ROUND(%s * %d) AS SIGNED) AS score_rank FROM posts WHERE %s",
+		PostRepository.selectColumns, match, match, searchRankScale, match)
+	return searchHits(ctx, sql, []interface{}{query, query, query}, after, limit)
+}
+
+// tsvectorSQL is the document of a post indexed by the GIN index of the migration
+// AddTsvectorIndexToPosts, the expression must stay the same for the index to be used.
+const tsvectorSQL = "to_tsvector('simple', coalesce(posts.title, '') || ' ' || coalesce(posts.content, ''))"
+
+// TSVectorSearch is the SearchBackend of PostgreSQL, it matches the tsvector of the title and
+// content of the posts against any term of the query and ranks them by ts_rank.
+type TSVectorSearch struct{}
+
+// Search implements SearchBackend.
+func (TSVectorSearch) Search(ctx context.Context, query string, after *SearchCursor, limit int) ([]PostHit, error) {
+	terms := []string{}
+	for t := range searchTerms(query) {
+		terms = append(terms, t)
+	}
+	sort.Strings(terms)
+	rank := fmt.Sprintf("ts_rank(%s, q)", tsvectorSQL)
+	sql := fmt.Sprintf("SELECT %s, %s AS score, ROUND((%s * %d)::numeric)::bigint AS score_rank FROM posts, to_tsquery('simple', ?) AS q WHERE %s @@ q",
+		PostRepository.selectColumns, rank, rank, searchRankScale, tsvectorSQL)
+	// the terms are letters and digits, none is an operator of tsquery
+	return searchHits(ctx, sql, []interface{}{strings.Join(terms, " | ")}, after, limit)
+}
+
+// searchHits runs the query of the hits with their score and score_rank, after the cursor if it's not nil.
+func searchHits(ctx context.Context, sql string, args []interface{}, after *SearchCursor, limit int) ([]PostHit, error) {
+	sql = "SELECT * FROM (" + sql + ") AS hits"
+	if after != nil {
+		sql += " WHERE score_rank < ? OR (score_rank = ? AND id < ?)"
+		args = append(args, after.Rank, after.Rank, after.Id)
+	}
+	sql += " ORDER BY score_rank DESC, id DESC" + dialect.Limit(limit, 0)
+	hits := []PostHit{}
+	if err := PostRepository.query(ctx, &hits, sql, args...); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// searchToken is a word of a text, start and end are its byte offsets.
+type searchToken struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits a text into lower case words of letters and digits.
+func tokenize(text string) []searchToken {
+	tokens := []searchToken{}
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			tokens = append(tokens, searchToken{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, searchToken{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+// searchTerms is the set of the distinct words of a query.
+func searchTerms(query string) map[string]bool {
+	terms := map[string]bool{}
+	for _, t := range tokenize(query) {
+		terms[t.term] = true
+	}
+	return terms
+}
+
+// highlight cuts a snippet of about length bytes of text around the first matched term,
+// the text is HTML escaped and the matched terms are wrapped in <mark> tags.
+func highlight(text string, terms map[string]bool, length int) string {
+	tokens := tokenize(text)
+	first := -1
+	for i, t := range tokens {
+		if terms[t.term] {
+			first = i
+			break
+		}
+	}
+	// the snippet starts at a word a third of its length before the first match
+	start, end := 0, len(text)
+	if first >= 0 {
+		for i := first; i >= 0 && tokens[first].start-tokens[i].start <= length/3; i-- {
+			start = tokens[i].start
+		}
+	}
+	if end-start > length {
+		end = start + length
+		for i := len(tokens) - 1; i >= 0; i-- {
+			if tokens[i].end <= end {
+				end = tokens[i].end
+				break
+			}
+		}
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, t := range tokens {
+		if t.start < start || t.end > end || !terms[t.term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:t.start]))
+		b.WriteString("<mark>" + html.EscapeString(text[t.start:t.end]) + "</mark>")
+		pos = t.end
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
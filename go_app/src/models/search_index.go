@@ -0,0 +1,131 @@
+package models
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// BM25 parameters of the MemorySearch ranking.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// MemorySearch is an in-process inverted index of the posts, the SearchBackend of SQLite
+// and the tests. It holds all the posts so it isn't meant for the databases of production. The hits are ranked by BM25 on the title and content.
+//
+// As a QueryObserver it notices the writes to the posts table, the index is rebuilt from
+// the primary on the next search after a write.
+type MemorySearch struct {
+	mu          sync.RWMutex
+	postings    map[string]map[int64]int // term => post id => term frequency
+	docs        map[int64]memoryDoc
+	totalLength int
+	stale       atomic.Bool
+}
+
+type memoryDoc struct {
+	post   Post
+	length int
+}
+
+// NewMemorySearch returns an empty MemorySearch, it's built on the first search.
+func NewMemorySearch() *MemorySearch {
+	s := &MemorySearch{}
+	s.stale.Store(true)
+	return s
+}
+
+// ObserveQuery implements QueryObserver, it marks the index stale on a write to the posts table.
+func (s *MemorySearch) ObserveQuery(ctx context.Context, e QueryEvent) {
+	fields := strings.Fields(e.SQL)
+	if len(fields) == 0 || e.Err != nil {
+		return
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "INSERT", "UPDATE", "DELETE", "REPLACE":
+		if m := tableRegexp.FindStringSubmatch(e.SQL); m != nil && strings.EqualFold(m[1], PostRepository.table) {
+			s.stale.Store(true)
+		}
+	}
+}
+
+// Rebuild indexes all the posts read from the primary.
+func (s *MemorySearch) Rebuild(ctx context.Context) error {
+	// a write during the rebuild marks it stale again
+	s.stale.Store(false)
+	posts, err := PostRepository.All(Primary(ctx))
+	if err != nil {
+		s.stale.Store(true)
+		return err
+	}
+	postings := map[string]map[int64]int{}
+	docs := make(map[int64]memoryDoc, len(posts))
+	totalLength := 0
+	for _, p := range posts {
+		tokens := tokenize(p.Title.V + " " + p.Content.V)
+		for _, t := range tokens {
+			if postings[t.term] == nil {
+				postings[t.term] = map[int64]int{}
+			}
+			postings[t.term][p.Id]++
+		}
+		docs[p.Id] = memoryDoc{post: p, length: len(tokens)}
+		totalLength += len(tokens)
+	}
+	s.mu.Lock()
+	s.postings, s.docs, s.totalLength = postings, docs, totalLength
+	s.mu.Unlock()
+	return nil
+}
+
+// Search implements SearchBackend.
+func (s *MemorySearch) Search(ctx context.Context, query string, after *SearchCursor, limit int) ([]PostHit, error) {
+	if s.stale.Load() {
+		if err := s.Rebuild(ctx); err != nil {
+			return nil, err
+		}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.docs) == 0 {
+		return []PostHit{}, nil
+	}
+	avgLength := float64(s.totalLength) / float64(len(s.docs))
+	scores := map[int64]float64{}
+	for term := range searchTerms(query) {
+		posting := s.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		n := float64(len(posting))
+		idf := math.Log(1 + (float64(len(s.docs))-n+0.5)/(n+0.5))
+		for id, tf := range posting {
+			f := float64(tf)
+			norm := 1 - bm25B + bm25B*float64(s.docs[id].length)/avgLength
+			scores[id] += idf * f * (bm25K1 + 1) / (f + bm25K1*norm)
+		}
+	}
+	hits := []PostHit{}
+	for id, score := range scores {
+		rank := ScoreRank(score)
+		if after != nil && (rank > after.Rank || rank == after.Rank && id >= after.Id) {
+			continue
+		}
+		hits = append(hits, PostHit{Post: s.docs[id].post, Score: score, Rank: rank})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].Id > hits[j].Id
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
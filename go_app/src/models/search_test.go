@@ -0,0 +1,58 @@
+package models_test
+
+import (
+	"fmt"
+	"testing"
+
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+)
+
+// TestSearchPagination checks the pages of the hits of the same score follow each other by id.
+func TestSearchPagination(t *testing.T) {
+	modeltest.Open(t)
+	m.SetSearchBackend(m.NewMemorySearch())
+	userId := modeltest.CreateUser(t, "search@example.com")
+	for i := 0; i < 5; i++ {
+		modeltest.CreatePost(t, userId, "Searched post title", "The same searched content of the posts")
+	}
+	modeltest.CreatePost(t, userId, "Another post title", "Some content without the term")
+
+	got := []int64{}
+	page := &m.SearchPage{PerPage: 2}
+	for {
+		hits, err := m.SearchPosts("searched", page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, h := range hits {
+			if h.Rank != m.ScoreRank(h.Score) {
+				t.Errorf("hit %d rank = %d, want %d", h.Id, h.Rank, m.ScoreRank(h.Score))
+			}
+			got = append(got, h.Id)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		page.Cursor = page.NextCursor
+	}
+	if want := "[5 4 3 2 1]"; fmt.Sprint(got) != want {
+		t.Errorf("ids of the pages = %v, want %s", got, want)
+	}
+}
+
+func TestScoreRank(t *testing.T) {
+	for _, tt := range []struct {
+		score float64
+		want  int64
+	}{
+		{0, 0},
+		{1.2345674, 1234567},
+		{1.2345675000001, 1234568},
+		{0.1 + 0.2, 300000},
+	} {
+		if got := m.ScoreRank(tt.score); got != tt.want {
+			t.Errorf("ScoreRank(%v) = %d, want %d", tt.score, got, tt.want)
+		}
+	}
+}
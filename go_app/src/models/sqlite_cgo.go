@@ -0,0 +1,23 @@
+//go:build cgo
+
+package models
+
+import (
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// translateSQLiteError maps the unique, primary key and foreign key constraint errors.
+func translateSQLiteError(err error) error {
+	var se sqlite3.Error
+	if errors.As(err, &se) {
+		switch se.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return ErrDuplicate
+		case sqlite3.ErrConstraintForeignKey:
+			return ErrForeignKey
+		}
+	}
+	return nil
+}
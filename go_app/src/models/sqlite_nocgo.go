@@ -0,0 +1,9 @@
+//go:build !cgo
+
+package models
+
+// translateSQLiteError translates nothing, the sqlite3 driver needs cgo so no sqlite error
+// is returned by a binary built without it.
+func translateSQLiteError(err error) error {
+	return nil
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// StmtCacheSize is the maximum count of prepared statements kept open per process,
+// the least recently used one is closed when it's exceeded.
+var StmtCacheSize = 100
+
+// StmtCacheStats are the counters of the prepared statement cache.
+type StmtCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+type stmtKey struct {
+	db    *sqlx.DB
+	query string
+}
+
+// cachedStmt is a prepared statement of the cache, it must be released after use.
+// An evicted statement is closed once the last user releases it.
+type cachedStmt struct {
+	*sqlx.Stmt
+	key     stmtKey
+	refs    int
+	evicted bool
+}
+
+var stmtCache = struct {
+	sync.Mutex
+	lru   *list.List
+	items map[stmtKey]*list.Element
+	stats StmtCacheStats
+}{lru: list.New(), items: map[stmtKey]*list.Element{}}
+
+// prepareStmt returns the cached statement of a rebound query on db, preparing it on a miss.
+func prepareStmt(ctx context.Context, db *sqlx.DB, query string) (*cachedStmt, error) {
+	key := stmtKey{db: db, query: query}
+	stmtCache.Lock()
+	if el, ok := stmtCache.items[key]; ok {
+		stmtCache.stats.Hits++
+		stmtCache.lru.MoveToFront(el)
+		cs := el.Value.(*cachedStmt)
+		cs.refs++
+		stmtCache.Unlock()
+		return cs, nil
+	}
+	stmtCache.stats.Misses++
+	stmtCache.Unlock()
+
+	stmt, err := db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	stmtCache.Lock()
+	defer stmtCache.Unlock()
+	if el, ok := stmtCache.items[key]; ok {
+		// prepared concurrently by another caller
+		stmt.Close()
+		cs := el.Value.(*cachedStmt)
+		cs.refs++
+		return cs, nil
+	}
+	cs := &cachedStmt{Stmt: stmt, key: key, refs: 1}
+	stmtCache.items[key] = stmtCache.lru.PushFront(cs)
+	for stmtCache.lru.Len() > StmtCacheSize {
+		evictStmt(stmtCache.lru.Back())
+		stmtCache.stats.Evictions++
+	}
+	return cs, nil
+}
+
+// release gives the statement back to the cache.
+func (cs *cachedStmt) release() {
+	stmtCache.Lock()
+	defer stmtCache.Unlock()
+	cs.refs--
+	if cs.evicted && cs.refs == 0 {
+		cs.Close()
+	}
+}
+
+// evictStmt removes an element from the cache, the lock must be held.
+func evictStmt(el *list.Element) {
+	cs := el.Value.(*cachedStmt)
+	stmtCache.lru.Remove(el)
+	delete(stmtCache.items, cs.key)
+	cs.evicted = true
+	if cs.refs == 0 {
+		cs.Close()
+	}
+}
+
+// purgeStmts closes the cached statements of db, or all of them for a nil db.
+func purgeStmts(db *sqlx.DB) {
+	stmtCache.Lock()
+	defer stmtCache.Unlock()
+	for el := stmtCache.lru.Front(); el != nil; {
+		next := el.Next()
+		if cs := el.Value.(*cachedStmt); db == nil || cs.key.db == db {
+			evictStmt(el)
+		}
+		el = next
+	}
+}
+
+// CloseStmtCache closes all the cached prepared statements, it's called on shutdown.
+func CloseStmtCache() {
+	purgeStmts(nil)
+}
+
+// GetStmtCacheStats returns the hit/miss/eviction counters and the size of the statement cache.
+func GetStmtCacheStats() StmtCacheStats {
+	stmtCache.Lock()
+	defer stmtCache.Unlock()
+	stats := stmtCache.stats
+	stats.Size = stmtCache.lru.Len()
+	return stats
+}
@@ -0,0 +1,73 @@
+package models_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestStmtCachePurgedOnOpen(t *testing.T) {
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "cache@example.com")
+	for i := 0; i < 2; i++ {
+		if _, err := m.FindUsers(userId); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := m.GetStmtCacheStats(); stats.Size == 0 || stats.Hits == 0 {
+		t.Fatalf("GetStmtCacheStats() = %+v, want cached statements and hits", stats)
+	}
+
+	old := m.DB
+	if err := m.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "other.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { old.Close() })
+	if stats := m.GetStmtCacheStats(); stats.Size != 0 {
+		t.Errorf("GetStmtCacheStats().Size after Open = %d, want 0", stats.Size)
+	}
+}
+
+func TestStmtCacheMetrics(t *testing.T) {
+	modeltest.Open(t)
+	reg := prometheus.NewRegistry()
+	if err := m.RegisterMetrics(reg); err != nil {
+		t.Fatal(err)
+	}
+	userId := modeltest.CreateUser(t, "metrics@example.com")
+	for i := 0; i < 2; i++ {
+		if _, err := m.FindUsers(userId); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := m.GetStmtCacheStats()
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	for _, f := range families {
+		for _, metric := range f.GetMetric() {
+			if c := metric.GetCounter(); c != nil {
+				values[f.GetName()] = c.GetValue()
+			} else if g := metric.GetGauge(); g != nil {
+				values[f.GetName()] = g.GetValue()
+			}
+		}
+	}
+	for name, want := range map[string]float64{
+		"models_stmt_cache_hits_total":      float64(stats.Hits),
+		"models_stmt_cache_misses_total":    float64(stats.Misses),
+		"models_stmt_cache_evictions_total": float64(stats.Evictions),
+		"models_stmt_cache_size":            float64(stats.Size),
+	} {
+		if got, ok := values[name]; !ok || got != want {
+			t.Errorf("%s = %v (found %v), want %v", name, got, ok, want)
+		}
+	}
+}
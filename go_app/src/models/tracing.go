@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of the query spans.
+const TracerName = "go_app/src/models"
+
+// dbSystems are the db.system values of the dialects.
+var dbSystems = map[string]string{
+	"mysql":   "mysql",
+	"pgx":     "postgresql",
+	"sqlite3": "sqlite",
+}
+
+// TracingObserver records a client span for every query, as a child of the span of the
+// query context, so the ...Context finders called with a request context are traced under it.
+type TracingObserver struct {
+	Tracer trace.Tracer
+}
+
+// NewTracingObserver returns a TracingObserver, a nil provider is otel.GetTracerProvider().
+func NewTracingObserver(tp trace.TracerProvider) *TracingObserver {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &TracingObserver{Tracer: tp.Tracer(TracerName)}
+}
+
+// ObserveQuery implements QueryObserver, the span covers the time from the start of the query.
+func (o *TracingObserver) ObserveQuery(ctx context.Context, e QueryEvent) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", dbSystems[dialect.Name()]),
+		attribute.String("db.statement", e.SQL),
+	}
+	op := ""
+	if fields := strings.Fields(e.SQL); len(fields) > 0 {
+		op = strings.ToUpper(fields[0])
+		attrs = append(attrs, attribute.String("db.operation", op))
+	}
+	if e.RowsAffected >= 0 {
+		attrs = append(attrs, attribute.Int64("db.rows_affected", e.RowsAffected))
+	}
+	_, span := o.Tracer.Start(ctx, strings.TrimSpace("db "+op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(e.Start),
+		trace.WithAttributes(attrs...),
+	)
+	if e.Err != nil {
+		span.RecordError(e.Err)
+		span.SetStatus(codes.Error, e.Err.Error())
+	}
+	span.End(trace.WithTimestamp(e.Start.Add(e.Duration)))
+}
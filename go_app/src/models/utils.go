@@ -1,17 +1,9 @@
 package models
 
 import (
-	"fmt"
+	"database/sql"
 )
 
-func buildIdsHolder(n int) (idsHolder string) {
-	idsHolder = "$1"
-	for i := 2; i <= n; i++ {
-		idsHolder += fmt.Sprintf(",$%d", i)
-	}
-	return
-}
-
 func allKeys(am map[string]interface{}) []string {
 	keys := make([]string, len(am))
 	i := 0
@@ -21,3 +13,36 @@ func allKeys(am map[string]interface{}) []string {
 	}
 	return keys
 }
+
+// insertReturningId executes a named INSERT and returns the id of the new record,
+// read with RETURNING id if the dialect supports it or else by LastInsertId.
+func insertReturningId(query string, arg interface{}) (int64, error) {
+	if dialect.Returning() {
+		rows, err := DB.NamedQuery(query+" RETURNING id", arg)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		var id int64
+		if rows.Next() {
+			err = rows.Scan(&id)
+		} else if err = rows.Err(); err == nil {
+			err = sql.ErrNoRows
+		}
+		return id, err
+	}
+	result, err := DB.NamedExec(query, arg)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// quoteColumns quotes the column names for the current dialect.
+func quoteColumns(cols []string) []string {
+	quoted := make([]string, len(cols))
+	for i, c := range cols {
+		quoted[i] = dialect.Quote(c)
+	}
+	return quoted
+}
@@ -0,0 +1,337 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Validation holds the attributes of a single write and collects the errors found on them.
+// With Partial set only the attributes present in Attrs are validated, that's
+// how the map-based updaters work.
+type Validation struct {
+	Model   string
+	Table   string
+	Id      int64
+	Attrs   map[string]interface{}
+	Partial bool
+	errs    []FieldError
+	err     error
+}
+
+// Value returns the attribute of a column and whether it's present in the write,
+// the driver.Valuer attributes like Null are unwrapped, so a NULL is nil.
+func (v *Validation) Value(col string) (interface{}, bool) {
+	val, ok := v.Attrs[col]
+	if valuer, isValuer := val.(driver.Valuer); isValuer {
+		if dv, err := valuer.Value(); err == nil {
+			return dv, ok
+		}
+	}
+	return val, ok
+}
+
+// Skip tells if the validators of a column should be skipped, i.e. a partial write without it.
+func (v *Validation) Skip(col string) bool {
+	_, ok := v.Attrs[col]
+	return v.Partial && !ok
+}
+
+// AddError adds an error on a field, the message is looked up by key in the loaded
+// locales the same way as Rails does, count is used for pluralization and %{count}.
+func (v *Validation) AddError(field, key string, count int) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: errorMessage(v.Model, field, key, count)})
+}
+
+// Fail aborts the validation with an error which isn't about the attributes,
+// e.g. the DB query of a validator failed.
+func (v *Validation) Fail(err error) {
+	if v.err == nil {
+		v.err = err
+	}
+}
+
+// Validator validates a field of a model in a Validation.
+type Validator interface {
+	Validate(v *Validation, field string)
+}
+
+// ValidatorFunc is a function adapter of Validator.
+type ValidatorFunc func(v *Validation, field string)
+
+// Validate calls f(v, field).
+func (f ValidatorFunc) Validate(v *Validation, field string) {
+	f(v, field)
+}
+
+type fieldValidator struct {
+	field     string
+	validator Validator
+}
+
+// validators are the registered validators by model name, in registration order.
+// It's filled up in the init functions and read-only afterwards.
+var validators = map[string][]fieldValidator{}
+
+// modelTables maps the model names to their table names for the DB-backed validators.
+var modelTables = map[string]string{}
+
+// modelTypes maps the model names to their struct types for the schema drift check.
+var modelTypes = map[string]reflect.Type{}
+
+// RegisterValidator adds validators on a field of a model, they run on every validated
+// write of the model after the ones built from the valid struct tags.
+// It's supposed to be called in an init function.
+func RegisterValidator(model, field string, vs ...Validator) {
+	for _, vv := range vs {
+		validators[model] = append(validators[model], fieldValidator{field: field, validator: vv})
+	}
+}
+
+// registerModel registers the table of a model and the validators built from the valid
+// tags of its struct.
+func registerModel(model, table string, s interface{}) {
+	modelTables[model] = table
+	t := reflect.TypeOf(s)
+	modelTypes[model] = t
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		col := f.Tag.Get("db")
+		tag := f.Tag.Get("valid")
+		if col == "" || tag == "" || tag == "-" {
+			continue
+		}
+		RegisterValidator(model, col, tagValidators(tag)...)
+	}
+}
+
+var tagParamRegexp = regexp.MustCompile(`^(\w+)\((.*)\)$`)
+
+// tagValidators parses a govalidator style tag, e.g. "required,length(10|50)".
+// Validators unknown here are delegated to the govalidator TagMap with an "invalid" error.
+func tagValidators(tag string) (vs []Validator) {
+	for _, opt := range splitTag(tag) {
+		name, param := opt, ""
+		if m := tagParamRegexp.FindStringSubmatch(opt); m != nil {
+			name, param = m[1], m[2]
+		}
+		switch name {
+		case "required":
+			vs = append(vs, Presence())
+		case "length", "runelength", "stringlength":
+			bounds := strings.SplitN(param, "|", 2)
+			min, _ := strconv.Atoi(bounds[0])
+			max := 0
+			if len(bounds) == 2 {
+				max, _ = strconv.Atoi(bounds[1])
+			}
+			vs = append(vs, Length(min, max))
+		case "matches":
+			vs = append(vs, Format(regexp.MustCompile(param)))
+		default:
+			if fn, ok := govalidator.TagMap[name]; ok {
+				vs = append(vs, stringCheck(fn))
+			}
+		}
+	}
+	return vs
+}
+
+// splitTag splits a valid tag on the commas outside of parentheses.
+func splitTag(tag string) []string {
+	opts := []string{}
+	depth, start := 0, 0
+	for i, r := range tag {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				opts = append(opts, tag[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(opts, tag[start:])
+}
+
+// Presence validates a field isn't nil, a blank string or a zero time, like validates presence: true.
+func Presence() Validator {
+	return ValidatorFunc(func(v *Validation, field string) {
+		if v.Skip(field) {
+			return
+		}
+		val, _ := v.Value(field)
+		if isBlank(val) {
+			v.AddError(field, "blank", 0)
+		}
+	})
+}
+
+// Length validates the count of characters of a string field, a zero max means no maximum.
+// A blank value is left to Presence.
+func Length(min, max int) Validator {
+	return ValidatorFunc(func(v *Validation, field string) {
+		s, ok := stringValue(v, field)
+		if !ok {
+			return
+		}
+		n := utf8.RuneCountInString(s)
+		if n < min {
+			v.AddError(field, "too_short", min)
+		} else if max > 0 && n > max {
+			v.AddError(field, "too_long", max)
+		}
+	})
+}
+
+// Format validates a string field matches the regexp.
+func Format(re *regexp.Regexp) Validator {
+	return stringCheck(re.MatchString)
+}
+
+func stringCheck(fn func(string) bool) Validator {
+	return ValidatorFunc(func(v *Validation, field string) {
+		s, ok := stringValue(v, field)
+		if ok && !fn(s) {
+			v.AddError(field, "invalid", 0)
+		}
+	})
+}
+
+// Uniqueness validates no other record of the model has the same value in the field,
+// caseSensitive false compares the values with LOWER() as Devise does for emails.
+func Uniqueness(caseSensitive bool) Validator {
+	return ValidatorFunc(func(v *Validation, field string) {
+		if v.Skip(field) {
+			return
+		}
+		val, _ := v.Value(field)
+		if isBlank(val) {
+			return
+		}
+		cond := field + " = ?"
+		if !caseSensitive {
+			cond = "LOWER(" + field + ") = LOWER(?)"
+		}
+		var c int64
+		sql := fmt.Sprintf("SELECT count(*) FROM %s WHERE %s AND id <> ?", v.Table, cond)
+		if err := DB.Get(&c, DB.Rebind(sql), val, v.Id); err != nil {
+			v.Fail(err)
+			return
+		}
+		if c > 0 {
+			v.AddError(field, "taken", 0)
+		}
+	})
+}
+
+// BelongsTo validates the record referenced by the foreign key exists, like a
+// required belongs_to association in Rails. The error is added on the association field.
+func BelongsTo(table, foreignKey string) Validator {
+	return ValidatorFunc(func(v *Validation, field string) {
+		if v.Skip(foreignKey) {
+			return
+		}
+		val, _ := v.Value(foreignKey)
+		if isBlank(val) || reflect.ValueOf(val).IsZero() {
+			v.AddError(field, "required", 0)
+			return
+		}
+		var c int64
+		sql := fmt.Sprintf("SELECT count(*) FROM %s WHERE id = ?", table)
+		if err := DB.Get(&c, DB.Rebind(sql), val); err != nil {
+			v.Fail(err)
+			return
+		}
+		if c == 0 {
+			v.AddError(field, "required", 0)
+		}
+	})
+}
+
+// validateAttrs runs the validators generated from the table columns and the ones
+// registered for the model on a write.
+func validateAttrs(model string, id int64, am map[string]interface{}, partial bool) error {
+	colValidators, err := loadColumnValidators(model)
+	if err != nil {
+		return err
+	}
+	v := &Validation{Model: model, Table: modelTables[model], Id: id, Attrs: am, Partial: partial}
+	for _, fv := range append(colValidators, validators[model]...) {
+		fv.validator.Validate(v, fv.field)
+	}
+	if v.err != nil {
+		return translateError(v.err)
+	}
+	if len(v.errs) > 0 {
+		return &ValidationError{Model: model, Errors: v.errs}
+	}
+	return nil
+}
+
+// validateStruct runs the validators registered for the model on all the columns of a model struct.
+func validateStruct(model string, s interface{}) error {
+	am := structAttrs(s)
+	id, _ := am["id"].(int64)
+	return validateAttrs(model, id, am, false)
+}
+
+// structAttrs returns the db tagged fields of a (pointer to) model struct keyed by column.
+func structAttrs(s interface{}) map[string]interface{} {
+	rv := reflect.Indirect(reflect.ValueOf(s))
+	t := rv.Type()
+	am := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		col := t.Field(i).Tag.Get("db")
+		if col == "" || col == "-" {
+			continue
+		}
+		am[col] = rv.Field(i).Interface()
+	}
+	return am
+}
+
+// stringValue returns a present string (or []byte) field, blank values are reported as absent.
+func stringValue(v *Validation, field string) (string, bool) {
+	if v.Skip(field) {
+		return "", false
+	}
+	val, _ := v.Value(field)
+	var s string
+	switch x := val.(type) {
+	case string:
+		s = x
+	case []byte:
+		s = string(x)
+	default:
+		return "", false
+	}
+	if strings.TrimSpace(s) == "" {
+		return "", false
+	}
+	return s, true
+}
+
+func isBlank(val interface{}) bool {
+	switch x := val.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(x) == ""
+	case []byte:
+		return strings.TrimSpace(string(x)) == ""
+	case time.Time:
+		return x.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// serviceName is the service.name of the spans, OTEL_SERVICE_NAME overrides it.
+const serviceName = "go_app"
+
+// setupTracing installs the global tracer provider exporting the spans to the named exporter:
+// "stdout" pretty prints them, "memory" keeps them in memory and "" disables the export.
+// The W3C traceparent and baggage headers are propagated in any case.
+// The returned function flushes and shuts down the provider.
+func setupTracing(exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	var exp sdktrace.SpanExporter
+	switch exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		var err error
+		if exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint()); err != nil {
+			return nil, err
+		}
+	case "memory":
+		exp = tracetest.NewInMemoryExporter()
+	default:
+		return nil, fmt.Errorf("Invalid trace exporter: %s", exporter)
+	}
+	tp, err := newTracerProvider(exp)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// newTracerProvider returns a tracer provider sampling every span and batching them to exp.
+func newTracerProvider(exp sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	), nil
+}
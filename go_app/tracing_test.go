@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	m "go_app/src/models"
+	"go_app/src/models/modeltest"
+)
+
+// TestTracing checks a request continuing the trace of its traceparent header is recorded as
+// a server span with the spans of its queries as children.
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		tp.Shutdown(t.Context())
+	})
+	m.AddQueryObserver(m.NewTracingObserver(tp))
+
+	modeltest.Open(t)
+	userId := modeltest.CreateUser(t, "tracing@example.com")
+	id := modeltest.CreatePost(t, userId, "A traced post", "Some post content here, long enough")
+	r, err := newRouter(routerOptions{graphQLMaxDepth: 10, graphQLMaxComplexity: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp.Reset()
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	req := httptest.NewRequest(http.MethodGet, "/posts/"+strconv.FormatInt(id, 10), nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("GET /posts/%d = %d, want 200", id, res.Code)
+	}
+
+	var server *tracetest.SpanStub
+	spans := exp.GetSpans()
+	for i, s := range spans {
+		if s.SpanKind == trace.SpanKindServer {
+			server = &spans[i]
+		}
+	}
+	if server == nil {
+		t.Fatalf("spans = %v, want a server span", spans)
+	}
+	if server.SpanContext.TraceID().String() != traceID || server.Parent.SpanID().String() != parentID {
+		t.Errorf("server span trace %s parent %s, want the trace %s parent %s of the traceparent",
+			server.SpanContext.TraceID(), server.Parent.SpanID(), traceID, parentID)
+	}
+	found := false
+	for _, s := range spans {
+		if s.SpanKind != trace.SpanKindClient || s.Parent.SpanID() != server.SpanContext.SpanID() {
+			continue
+		}
+		found = true
+		attrs := map[string]string{}
+		for _, kv := range s.Attributes {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+		if attrs["db.system"] != "sqlite" || attrs["db.statement"] == "" {
+			t.Errorf("DB span %s attributes = %v, want db.system sqlite and a db.statement", s.Name, attrs)
+		}
+	}
+	if !found {
+		t.Errorf("spans = %v, want a DB span child of the server span", spans)
+	}
+}